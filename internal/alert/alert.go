@@ -0,0 +1,368 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amirdaraby/titop/internal/collect/cpu"
+	"github.com/amirdaraby/titop/internal/collect/disk"
+	"github.com/amirdaraby/titop/internal/collect/mem"
+	"github.com/amirdaraby/titop/internal/collect/proc"
+	"github.com/amirdaraby/titop/internal/format"
+)
+
+// Metrics a rule can watch
+const (
+	METRIC_CPU         = "cpu"         // average of all cores, percent
+	METRIC_MEMORY      = "memory"      // percent
+	METRIC_SWAP        = "swap"        // percent
+	METRIC_PROCESS_CPU = "process.cpu" // percent, of the busiest process running Target
+	METRIC_PROCESS_RSS = "process.rss" // bytes, of the largest process running Target
+	METRIC_DISK        = "disk"        // percent of the filesystem mounted on Target
+	METRIC_DISK_INODES = "disk.inodes" // percent of the inodes of the filesystem mounted on Target
+)
+
+// Panels an alert flashes, by the metric it watches
+const (
+	PANEL_CPU       = "cpu"
+	PANEL_MEMORY    = "memory"
+	PANEL_PROCESSES = "processes"
+	PANEL_DISK      = "disk"
+)
+
+// A rule clears once its value drops this share below the threshold, unless
+// it sets its own clear value
+const DEFAULT_HYSTERESIS = 0.05
+
+// Multipliers of size suffixes in thresholds, as powers of 1024
+var sizeSuffixes = map[string]float64{
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+var rules []*Rule
+
+// Threshold is a number, optionally written with a trailing "%" or a size
+// suffix such as "4G"
+type Threshold float64
+
+// Rule fires once its metric stays above Above for For, and clears once it
+// drops below Clear.
+type Rule struct {
+	Name    string     `json:"name"`
+	Metric  string     `json:"metric"` // one of the METRIC_ constants
+	Target  string     `json:"target"` // process command or mount point, for the metrics that need one
+	Above   Threshold  `json:"above"`
+	Clear   *Threshold `json:"clear"`
+	For     string     `json:"for"`     // e.g. "30s"; fires right away when empty
+	Quiet   bool       `json:"quiet"`   // don't ring the terminal bell when firing
+	Command string     `json:"command"` // run with sh -c when firing
+	Log     string     `json:"log"`     // file each firing and clearing is appended to
+
+	duration   time.Duration
+	aboveSince time.Time
+	firing     bool
+	value      float64
+}
+
+type config struct {
+	Rules []*Rule `json:"rules"`
+}
+
+// Sample is what rules are evaluated against
+type Sample struct {
+	CPU         cpu.CPU
+	Memory      mem.Memory
+	Processes   []proc.Process
+	Filesystems []disk.Filesystem
+}
+
+// Event is a rule that started or stopped firing
+type Event struct {
+	Rule   *Rule
+	Firing bool
+	Value  float64
+	At     time.Time
+}
+
+// Load reads alert rules from a JSON file of the form
+// {"rules": [{"name": "hot", "metric": "cpu", "above": 90, "for": "30s"}]}
+func Load(path string) ([]*Rule, error) {
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var c config
+
+	if err := json.Unmarshal(content, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i, r := range c.Rules {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("%s: rule %d: %w", path, i+1, err)
+		}
+	}
+
+	return c.Rules, nil
+}
+
+func SetRules(loaded []*Rule) {
+	rules = loaded
+}
+
+func (r *Rule) validate() error {
+	switch r.Metric {
+	case METRIC_CPU, METRIC_MEMORY, METRIC_SWAP:
+	case METRIC_PROCESS_CPU, METRIC_PROCESS_RSS, METRIC_DISK, METRIC_DISK_INODES:
+		if r.Target == "" {
+			return fmt.Errorf("metric %q needs a target", r.Metric)
+		}
+	default:
+		return fmt.Errorf("unknown metric %q", r.Metric)
+	}
+
+	// Values between the two would fire and clear on alternate samples
+	if r.Clear != nil && *r.Clear >= r.Above {
+		return fmt.Errorf("clear %g is not below above %g", float64(*r.Clear), float64(r.Above))
+	}
+
+	if r.For != "" {
+		duration, err := time.ParseDuration(r.For)
+		if err != nil {
+			return err
+		}
+		r.duration = duration
+	}
+
+	if r.Name == "" {
+		r.Name = strings.TrimSpace(r.Metric + " " + r.Target)
+	}
+
+	return nil
+}
+
+// Evaluate checks every rule against a sample, returning the rules that
+// started or stopped firing. Their command and log actions are run here;
+// the caller takes care of what is shown on screen.
+func Evaluate(sample Sample, now time.Time) []Event {
+	var events []Event
+
+	for _, r := range rules {
+		value, found := r.measure(sample)
+		r.value = value
+
+		switch {
+		case !r.firing && found && value > float64(r.Above):
+			if r.aboveSince.IsZero() {
+				r.aboveSince = now
+			}
+
+			if now.Sub(r.aboveSince) >= r.duration {
+				r.firing = true
+				events = append(events, Event{Rule: r, Firing: true, Value: value, At: now})
+			}
+		case !r.firing:
+			r.aboveSince = time.Time{}
+		case !found || value < r.clearValue():
+			// A watched process that exited or a filesystem that got
+			// unmounted clears its rule too
+			r.firing = false
+			r.aboveSince = time.Time{}
+			events = append(events, Event{Rule: r, Firing: false, Value: value, At: now})
+		}
+	}
+
+	for _, e := range events {
+		e.dispatch()
+	}
+
+	return events
+}
+
+// Firing lists the rules firing since the last evaluation
+func Firing() []*Rule {
+	var firing []*Rule
+
+	for _, r := range rules {
+		if r.firing {
+			firing = append(firing, r)
+		}
+	}
+
+	return firing
+}
+
+// Panel is the part of the screen showing what the rule watches
+func (r *Rule) Panel() string {
+	switch r.Metric {
+	case METRIC_CPU:
+		return PANEL_CPU
+	case METRIC_MEMORY, METRIC_SWAP:
+		return PANEL_MEMORY
+	case METRIC_PROCESS_CPU, METRIC_PROCESS_RSS:
+		return PANEL_PROCESSES
+	}
+
+	return PANEL_DISK
+}
+
+func (r *Rule) clearValue() float64 {
+	if r.Clear != nil {
+		return float64(*r.Clear)
+	}
+
+	return float64(r.Above) * (1 - DEFAULT_HYSTERESIS)
+}
+
+// measure returns the current value of the rule's metric, and false when
+// there is nothing to measure, e.g. the watched process isn't running
+func (r *Rule) measure(sample Sample) (float64, bool) {
+	switch r.Metric {
+	case METRIC_CPU:
+		return float64(sample.CPU.Usage), len(sample.CPU.Cores) > 0
+	case METRIC_MEMORY:
+		return float64(sample.Memory.Usage), sample.Memory.Total > 0
+	case METRIC_SWAP:
+		if sample.Memory.Swap == nil {
+			return 0, false
+		}
+		return float64(sample.Memory.Swap.Usage), true
+	case METRIC_PROCESS_CPU, METRIC_PROCESS_RSS:
+		var value float64
+		found := false
+
+		for _, p := range sample.Processes {
+			if p.Exited || p.Command != r.Target {
+				continue
+			}
+
+			v := float64(p.CpuUsage)
+			if r.Metric == METRIC_PROCESS_RSS {
+				v = float64(p.Memory.Resident)
+			}
+
+			value = max(value, v)
+			found = true
+		}
+
+		return value, found
+	case METRIC_DISK, METRIC_DISK_INODES:
+		for _, fs := range sample.Filesystems {
+			if fs.MountPoint != r.Target {
+				continue
+			}
+
+			if r.Metric == METRIC_DISK_INODES {
+				return float64(fs.InodeUsage), fs.HasInodes
+			}
+
+			return float64(fs.Usage), true
+		}
+	}
+
+	return 0, false
+}
+
+// dispatch runs the command and log actions of an event
+func (e Event) dispatch() {
+	state := "cleared"
+	if e.Firing {
+		state = "firing"
+	}
+
+	if e.Rule.Log != "" {
+		line := fmt.Sprintf("%s %s %s: %s %s\n", e.At.Format(time.RFC3339), state, e.Rule.Name, e.Rule.Metric, e.Rule.format(e.Value))
+
+		// Logging is best effort, a broken log file shouldn't stop titop
+		if f, err := os.OpenFile(e.Rule.Log, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644); err == nil {
+			f.WriteString(line)
+			f.Close()
+		}
+	}
+
+	if e.Rule.Command != "" && e.Firing {
+		cmd := exec.Command("sh", "-c", e.Rule.Command)
+		cmd.Env = append(os.Environ(),
+			"TITOP_ALERT="+e.Rule.Name,
+			"TITOP_METRIC="+e.Rule.Metric,
+			"TITOP_VALUE="+strconv.FormatFloat(e.Value, 'f', 1, 64),
+		)
+
+		if err := cmd.Start(); err == nil {
+			// Reap it without waiting for it
+			go cmd.Wait()
+		}
+	}
+}
+
+// Describe names the rule with its current value
+func (r *Rule) Describe() string {
+	return r.Name + " " + r.format(r.value)
+}
+
+func (r *Rule) format(value float64) string {
+	if r.Metric == METRIC_PROCESS_RSS {
+		return format.Bytes(int64(value))
+	}
+
+	return strconv.FormatFloat(value, 'f', 1, 64) + "%"
+}
+
+func (t *Threshold) UnmarshalJSON(data []byte) error {
+	var number float64
+
+	if err := json.Unmarshal(data, &number); err == nil {
+		*t = Threshold(number)
+		return nil
+	}
+
+	var text string
+
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("threshold %s is neither a number nor a string", data)
+	}
+
+	value, err := parseThreshold(text)
+	if err != nil {
+		return err
+	}
+
+	*t = Threshold(value)
+
+	return nil
+}
+
+// parseThreshold reads "90", "90%", "4G", "512MiB" and the like
+func parseThreshold(text string) (float64, error) {
+	text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "%"))
+	upper := strings.ToUpper(text)
+
+	multiplier := 1.0
+	for _, unit := range []string{"IB", "B"} {
+		upper = strings.TrimSuffix(upper, unit)
+	}
+
+	if len(upper) > 0 {
+		if m, exists := sizeSuffixes[upper[len(upper)-1:]]; exists {
+			multiplier = m
+			upper = upper[:len(upper)-1]
+		}
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(upper), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid threshold %q", text)
+	}
+
+	return number * multiplier, nil
+}
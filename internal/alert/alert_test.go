@@ -0,0 +1,130 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amirdaraby/titop/internal/collect/cpu"
+)
+
+// A value of the CPU metric, seconds after the first sample
+type reading struct {
+	at    int
+	value float32
+}
+
+func TestEvaluate(t *testing.T) {
+	clearAt := Threshold(80)
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		readings []reading
+		want     []bool // firing state of the events, in order
+		wantAt   []int  // seconds of the events
+	}{
+		{
+			name:     "oscillating around above fires once",
+			rule:     Rule{Metric: METRIC_CPU, Above: 90},
+			readings: []reading{{0, 91}, {1, 89}, {2, 91}, {3, 89}, {4, 91}, {5, 85}},
+			want:     []bool{true, false},
+			wantAt:   []int{0, 5},
+		},
+		{
+			name:     "waits for For to elapse",
+			rule:     Rule{Metric: METRIC_CPU, Above: 90, For: "30s"},
+			readings: []reading{{0, 95}, {10, 95}, {20, 95}, {29, 95}, {30, 95}},
+			want:     []bool{true},
+			wantAt:   []int{30},
+		},
+		{
+			name:     "a dip restarts For",
+			rule:     Rule{Metric: METRIC_CPU, Above: 90, For: "30s"},
+			readings: []reading{{0, 95}, {20, 80}, {25, 95}, {50, 95}, {55, 95}},
+			want:     []bool{true},
+			wantAt:   []int{55},
+		},
+		{
+			name:     "clears 5% below above by default",
+			rule:     Rule{Metric: METRIC_CPU, Above: 100},
+			readings: []reading{{0, 101}, {1, 96}, {2, 95}, {3, 94.9}},
+			want:     []bool{true, false},
+			wantAt:   []int{0, 3},
+		},
+		{
+			name:     "clears below its own clear value",
+			rule:     Rule{Metric: METRIC_CPU, Above: 90, Clear: &clearAt},
+			readings: []reading{{0, 91}, {1, 85}, {2, 81}, {3, 79}},
+			want:     []bool{true, false},
+			wantAt:   []int{0, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			if err := rule.validate(); err != nil {
+				t.Fatal(err)
+			}
+			SetRules([]*Rule{&rule})
+			defer SetRules(nil)
+
+			start := time.Now()
+			var got []bool
+			var gotAt []int
+
+			for _, r := range tt.readings {
+				sample := Sample{CPU: cpu.CPU{Usage: r.value, Cores: []cpu.Core{{Usage: r.value}}}}
+
+				for _, e := range Evaluate(sample, start.Add(time.Duration(r.at)*time.Second)) {
+					got = append(got, e.Firing)
+					gotAt = append(gotAt, r.at)
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("events %v at %v, want %v at %v", got, gotAt, tt.want, tt.wantAt)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] || gotAt[i] != tt.wantAt[i] {
+					t.Fatalf("events %v at %v, want %v at %v", got, gotAt, tt.want, tt.wantAt)
+				}
+			}
+		})
+	}
+}
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"4G", 4 << 30},
+		{"95%", 95},
+		{"512MiB", 512 << 20},
+		{"90", 90},
+	}
+
+	for _, tt := range tests {
+		got, err := parseThreshold(tt.text)
+		if err != nil {
+			t.Fatalf("parseThreshold(%q): %v", tt.text, err)
+		}
+		if got != tt.want {
+			t.Errorf("parseThreshold(%q) = %g, want %g", tt.text, got, tt.want)
+		}
+	}
+
+	if _, err := parseThreshold("lots"); err == nil {
+		t.Error("parseThreshold(\"lots\") gave no error")
+	}
+}
+
+func TestValidateRejectsClearAboveThreshold(t *testing.T) {
+	clearAt := Threshold(95)
+	rule := Rule{Metric: METRIC_CPU, Above: 90, Clear: &clearAt}
+
+	if err := rule.validate(); err == nil {
+		t.Error("a clear value above the threshold was accepted")
+	}
+}
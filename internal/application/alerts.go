@@ -0,0 +1,74 @@
+package application
+
+import (
+	"strings"
+	"time"
+
+	"github.com/amirdaraby/titop/internal/alert"
+	"github.com/amirdaraby/titop/internal/collect"
+	"github.com/amirdaraby/titop/internal/collect/cpu"
+	"github.com/amirdaraby/titop/internal/collect/disk"
+	"github.com/amirdaraby/titop/internal/collect/mem"
+	"github.com/amirdaraby/titop/internal/collect/proc"
+	"github.com/gdamore/tcell/v2"
+)
+
+// evaluateAlerts checks the alert rules against the latest sample, ringing
+// the bell for the rules that just fired
+func (ui *UI) evaluateAlerts(snapshot collect.Snapshot) {
+	var sample alert.Sample
+	sample.CPU, _ = snapshot[cpu.NAME].(cpu.CPU)
+	sample.Memory, _ = snapshot[mem.NAME].(mem.Memory)
+	sample.Processes, _ = snapshot[proc.NAME].([]proc.Process)
+	sample.Filesystems, _ = snapshot[disk.NAME].([]disk.Filesystem)
+
+	events := alert.Evaluate(sample, time.Now())
+
+	for _, e := range events {
+		if e.Firing && !e.Rule.Quiet {
+			ui.screen.Beep()
+		}
+	}
+
+	ui.firingAlerts = alert.Firing()
+
+	// Panels of firing alerts flash by switching style on every refresh
+	ui.alertFlash = !ui.alertFlash
+}
+
+// panelStyle is the style of a panel's title, flashing while an alert on
+// what the panel shows is firing
+func (ui *UI) panelStyle(panel string) tcell.Style {
+	if !ui.alertFlash {
+		return ui.styles.text
+	}
+
+	for _, r := range ui.firingAlerts {
+		if r.Panel() == panel {
+			return ui.styles.warningText.Reverse(true)
+		}
+	}
+
+	return ui.styles.text
+}
+
+// renderAlerts lists the firing alerts, ending left of x and starting no
+// further left than start
+func (ui *UI) renderAlerts(start, x, y int) {
+	if len(ui.firingAlerts) == 0 || x <= start {
+		return
+	}
+
+	descriptions := make([]string, len(ui.firingAlerts))
+	for i, r := range ui.firingAlerts {
+		descriptions[i] = r.Describe()
+	}
+
+	text := "ALERT " + strings.Join(descriptions, ", ")
+
+	width, _ := ui.screen.Size()
+	text = truncateString(text, min(width/2, x-start))
+	x -= len([]rune(text))
+
+	emitStr(ui.screen, x, y, ui.styles.warningText, text)
+}
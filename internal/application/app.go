@@ -2,50 +2,128 @@ package application
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/amirdaraby/titop/internal/collect"
-	"github.com/amirdaraby/titop/internal/collect/cpu"
-	"github.com/amirdaraby/titop/internal/collect/mem"
-	"github.com/amirdaraby/titop/internal/collect/proc"
+	"github.com/amirdaraby/titop/internal/config"
 	"github.com/amirdaraby/titop/internal/shared"
+	"github.com/gdamore/tcell/v2"
 )
 
-func Run(parentCtx context.Context) error {
-	ctx, cancel := context.WithCancel(parentCtx)
+// Input events waiting for the event loop
+const EVENT_QUEUE_SIZE = 64
+
+// Results of background work waiting for the event loop
+const MESSAGE_QUEUE_SIZE = 8
 
-	cpuRes := make(chan cpu.CPU, 1)
-	memRes := make(chan mem.Memory, 1)
-	processesRes := make(chan []proc.Process, 1)
+// message applies the result of work done off the event loop to the UI state
+type message func(ui *UI)
+
+// Run shows the monitor until the user quits or ctx is cancelled, and
+// restores the terminal before returning. A single event loop owns the UI
+// state: samples and input reach it as messages. settingsErr, from loading
+// settings, is shown as a notice.
+func Run(parentCtx context.Context, settings config.Config, settingsErr error) error {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
 
-	ui, err := Init(cancel)
+	ui, err := Init(cancel, settings)
 
 	if err != nil {
 		return err
 	}
 
-	go ui.pollAndListenToEvents(cancel)
+	// Runs on panics of the event loop too, so a crash doesn't leave the
+	// terminal in raw mode. Collectors and background work recover from
+	// their own panics, which would skip this.
+	defer ui.screen.Fini()
+
+	if settingsErr != nil {
+		ui.notice = notice{text: "settings not loaded: " + settingsErr.Error(), until: time.Now().Add(NOTICE_DURATION)}
+	}
+
+	return ui.run(ctx)
+}
+
+// run is the event loop, returning once the user quits or ctx is cancelled
+func (ui *UI) run(ctx context.Context) error {
+	snapshots := make(chan collect.Snapshot, 1)
+	events := make(chan tcell.Event, EVENT_QUEUE_SIZE)
+
+	ui.done = ctx.Done()
+
+	go ui.screen.ChannelEvents(events, ctx.Done())
+	go sample(ctx, snapshots)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case snapshot := <-snapshots:
+			ui.update(snapshot)
+		case ev := <-events:
+			if ui.handleEvent(ev) {
+				return nil
+			}
+		case msg := <-ui.messages:
+			msg(ui)
+		}
+
+		// Draw once whatever is queued has been applied, so a burst of
+		// keys costs a single redraw
+		if len(snapshots) == 0 && len(events) == 0 && len(ui.messages) == 0 {
+			ui.draw()
+		}
+	}
+}
+
+// runInBackground runs work off the event loop, so slow reads of /proc don't
+// hold up input, and hands the message it returns to the loop. Should work
+// panic, failed is applied instead and the panic is shown as a notice.
+func (ui *UI) runInBackground(work func() message, failed message) {
+	messages, done := ui.messages, ui.done
 
 	go func() {
-	loop:
-		for {
+		var msg message
+
+		defer func() {
+			if p := recover(); p != nil {
+				msg = func(ui *UI) {
+					failed(ui)
+					ui.notice = notice{text: fmt.Sprint("background work failed: ", p), until: time.Now().Add(NOTICE_DURATION)}
+				}
+			}
+
 			select {
-			case <-ctx.Done():
-				break loop
-			default:
-				shared.Refreshing()
-				collect.Collect(cpuRes, memRes, processesRes)
-				time.Sleep(time.Millisecond * time.Duration(shared.GetRefreshRate()))
+			case messages <- msg:
+			case <-done:
 			}
-		}
+		}()
+
+		msg = work()
 	}()
+}
 
+// sample collects a snapshot on every tick of the refresh rate until ctx is
+// cancelled. Collectors too slow for a tick don't hold the others back.
+func sample(ctx context.Context, snapshots chan<- collect.Snapshot) {
 	for {
-		cpu := <-cpuRes
-		mem := <-memRes
-		proc := <-processesRes
-		
+		refreshRate := time.Millisecond * time.Duration(shared.GetRefreshRate())
+		tickStart := time.Now()
+
+		snapshot := collect.Collect(ctx, refreshRate)
 
-		ui.update(cpu, mem, proc)
+		select {
+		case <-ctx.Done():
+			return
+		case snapshots <- snapshot:
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(refreshRate - time.Since(tickStart)):
+		}
 	}
 }
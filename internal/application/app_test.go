@@ -0,0 +1,127 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/amirdaraby/titop/internal/collect"
+	"github.com/amirdaraby/titop/internal/collect/cgroup"
+	"github.com/amirdaraby/titop/internal/collect/cpu"
+	"github.com/amirdaraby/titop/internal/collect/disk"
+	"github.com/amirdaraby/titop/internal/collect/mem"
+	"github.com/amirdaraby/titop/internal/collect/proc"
+	"github.com/amirdaraby/titop/internal/config"
+	"github.com/amirdaraby/titop/internal/shared"
+)
+
+// Keys the test presses, switching views and the settings the collectors read
+var keys = []rune("HSaa,.pgUcsdlCF12m+-<>uipF")
+
+// TestConcurrentCollection runs the event loop while samples are collected
+// and the collector settings change from other goroutines. It is meant to be
+// run with -race.
+func TestConcurrentCollection(t *testing.T) {
+	// Settings toggled by keys are saved, keep them out of the home directory
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if err := shared.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once, a collector registered twice would run alongside itself
+	if len(collect.Names()) == 0 {
+		collect.Register(cpu.Collector{}, mem.Collector{}, proc.Collector{}, cgroup.Collector{}, disk.Collector{})
+	}
+
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(120, 40)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ui := newUI(screen, config.Config{})
+	stopped := make(chan error, 1)
+
+	go func() {
+		stopped <- ui.run(ctx)
+	}()
+
+	// Posting rather than injecting keys, which would block for good once
+	// the loop stopped taking events
+	press := func(key tcell.Key, r rune) {
+		for screen.PostEvent(tcell.NewEventKey(key, r, tcell.ModNone)) != nil && ctx.Err() == nil {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Escape would quit from the process list, so views are left through
+	// the loop instead
+	show := func(view viewMode) {
+		select {
+		case ui.messages <- func(ui *UI) { ui.setView(view) }:
+		case <-ctx.Done():
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 20; i++ {
+			collect.Collect(ctx, 200*time.Millisecond)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100; i++ {
+			proc.SetThreads(i%2 == 0)
+			proc.SetSmapsRollup(i%3 == 0)
+			proc.SetCommandLines(i%4 != 0)
+			shared.IncreaseRefreshRate(100)
+			shared.DecreaseRefreshRate(100)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 4; i++ {
+			for _, key := range keys {
+				press(tcell.KeyRune, key)
+				press(tcell.KeyDown, 0)
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			// Inspect a process, reading each tab in the background
+			show(PROCESS_VIEW)
+			for _, key := range []tcell.Key{tcell.KeyEnter, tcell.KeyTab, tcell.KeyTab, tcell.KeyTab} {
+				press(key, 0)
+				time.Sleep(20 * time.Millisecond)
+			}
+			show(PROCESS_VIEW)
+		}
+	}()
+
+	wg.Wait()
+	press(tcell.KeyCtrlC, 0)
+
+	if err := <-stopped; err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("the event loop didn't quit on ctrl+c in time")
+	}
+}
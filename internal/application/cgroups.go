@@ -0,0 +1,147 @@
+package application
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/amirdaraby/titop/internal/collect/cgroup"
+	"github.com/amirdaraby/titop/internal/collect/proc"
+	"github.com/amirdaraby/titop/internal/format"
+)
+
+const CGROUP_INDENT = "  "
+
+// renderCgroupList draws the cgroup tree with the usage each cgroup reports
+// for its whole subtree
+func (ui *UI) renderCgroupList(dim displayDimensions, startY, maxHeight int) {
+	if len(ui.cgroups) == 0 {
+		emitStr(ui.screen, dim.startWidth, startY, ui.styles.text, "No cgroup v2 hierarchy found")
+		return
+	}
+
+	processCounts := ui.countProcessesPerCgroup()
+
+	otherColumnsWidth := 6 + 6 + 7 + MEMORY_COLUMN_WIDTH*2 + 1 + IO_COLUMN_WIDTH*2 // PROCS + PIDS + CPU% + MEM/LIMIT + READ/s + WRITE/s
+	nameWidth := max(dim.totalWidth-otherColumnsWidth-6, GROUP_NAME_MIN_WIDTH)
+
+	header := fmt.Sprintf("%-*s %6s %6s %7s %*s %*s %*s",
+		nameWidth, "CGROUP", "PROCS", "PIDS", "CPU%",
+		MEMORY_COLUMN_WIDTH*2+1, "MEM/LIMIT", IO_COLUMN_WIDTH, "READ/s", IO_COLUMN_WIDTH, "WRITE/s")
+	emitStr(ui.screen, dim.startWidth, startY, ui.styles.text, header)
+	startY++
+
+	visibleCount := max(0, maxHeight-1) // -1 for header
+	endIdx := min(len(ui.cgroups), ui.scrollOffset+visibleCount)
+
+	for i := ui.scrollOffset; i < endIdx; i++ {
+		cg := ui.cgroups[i]
+
+		pids := "-"
+		if cg.HasPids {
+			pids = fmt.Sprint(cg.PidsCurrent)
+		}
+
+		memory := "-"
+		if cg.HasMemory {
+			memory = format.Bytes(cg.MemoryCurrent) + "/max"
+		}
+		if cg.HasMemory && cg.MemoryMax > 0 {
+			memory = format.Bytes(cg.MemoryCurrent) + "/" + format.Bytes(cg.MemoryMax)
+		}
+
+		line := fmt.Sprintf("%-*s %6d %6s %6.1f%% %*s %*s %*s",
+			nameWidth, truncateString(cgroupLabel(cg), nameWidth),
+			processCounts[cg.Path],
+			pids,
+			cg.CpuUsage,
+			MEMORY_COLUMN_WIDTH*2+1, memory,
+			IO_COLUMN_WIDTH, format.Rate(cg.ReadRate),
+			IO_COLUMN_WIDTH, format.Rate(cg.WriteRate),
+		)
+
+		style := ui.styles.text
+		if i == ui.selectedRow {
+			style = ui.styles.selectedText
+		}
+
+		emitStr(ui.screen, dim.startWidth, startY+(i-ui.scrollOffset), style, line)
+	}
+}
+
+// cgroupLabel indents a cgroup under its parent and names what it runs
+func cgroupLabel(cg cgroup.Cgroup) string {
+	label := path.Base(cg.Path)
+
+	switch cg.Kind {
+	case cgroup.KIND_DOCKER, cgroup.KIND_CONTAINERD, cgroup.KIND_PODMAN, cgroup.KIND_LXC:
+		label = cg.Kind + " " + cg.Name
+	}
+
+	return strings.Repeat(CGROUP_INDENT, cg.Depth) + label
+}
+
+// countProcessesPerCgroup counts the processes in every cgroup's subtree
+func (ui *UI) countProcessesPerCgroup() map[string]int {
+	counts := make(map[string]int)
+
+	for _, p := range ui.allProcesses {
+		if p.Exited || p.Cgroup == "" {
+			continue
+		}
+
+		for cgroupPath := p.Cgroup; ; cgroupPath = path.Dir(cgroupPath) {
+			counts[cgroupPath]++
+
+			if cgroupPath == "/" {
+				break
+			}
+		}
+	}
+
+	return counts
+}
+
+// inCgroup reports whether processCgroup is cgroupPath or one of its descendants
+func inCgroup(processCgroup, cgroupPath string) bool {
+	if cgroupPath == "/" || processCgroup == cgroupPath {
+		return processCgroup != ""
+	}
+
+	return strings.HasPrefix(processCgroup, cgroupPath+"/")
+}
+
+// groupByService groups processes by the cgroup path of their systemd unit,
+// so units of the same name under different user managers stay apart
+func groupByService(processes []proc.Process) []proc.Group {
+	return proc.GroupBy(processes, func(p proc.Process) string {
+		_, unitPath := cgroup.Unit(p.Cgroup)
+		return unitPath
+	})
+}
+
+// serviceLabel names the unit of a group, noting the user manager it runs under
+func serviceLabel(group proc.Group) string {
+	unit, unitPath := cgroup.Unit(group.Name)
+
+	if unit == "" {
+		return "(no unit)"
+	}
+
+	if manager, _ := cgroup.Unit(path.Dir(unitPath)); manager != "" {
+		return fmt.Sprintf("%s (%s)", unit, manager)
+	}
+
+	return unit
+}
+
+// serviceMemoryLimit returns the memory.max of a unit's cgroup, or "-" when unlimited
+func (ui *UI) serviceMemoryLimit(group proc.Group) string {
+	for _, cg := range ui.cgroups {
+		if cg.Path == group.Name && cg.HasMemory && cg.MemoryMax > 0 {
+			return format.Bytes(cg.MemoryMax)
+		}
+	}
+
+	return "-"
+}
@@ -0,0 +1,468 @@
+package application
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/amirdaraby/titop/internal/collect/proc"
+	"github.com/amirdaraby/titop/internal/config"
+	"github.com/amirdaraby/titop/internal/format"
+)
+
+const (
+	MAX_COLUMN_WIDTH        = 16 // widest a column grows to fit its values
+	CGROUP_COLUMN_MAX_WIDTH = 40
+	COLUMN_NAME_WIDTH       = 8
+)
+
+// column is one column the process list can show
+type column struct {
+	name        string // header, and the name the config file uses
+	description string
+	minWidth    int
+	maxWidth    int  // MAX_COLUMN_WIDTH when 0
+	left        bool // left-aligned, as text reads best
+	fill        bool // takes whatever width the other columns leave
+
+	header func(ui *UI) string // the name when nil
+	hidden func(ui *UI) bool   // for columns of data that isn't always collected
+	value  func(ui *UI, row processRow) string
+}
+
+// Every column of the process list, in the order the chooser lists them
+var processColumns = []column{
+	{name: "PID", description: "process ID", minWidth: 7, left: true, value: func(ui *UI, row processRow) string {
+		if row.groupSize == 0 {
+			return row.ID
+		}
+		if ui.expandedCommands[row.Command] {
+			return GROUP_EXPANDED_MARKER
+		}
+		return GROUP_COLLAPSED_MARKER
+	}},
+	{name: "PPID", description: "parent process ID", minWidth: 7, left: true, value: func(ui *UI, row processRow) string {
+		return row.ParentID
+	}},
+	{name: "USER", description: "owner", minWidth: 8, left: true, value: func(ui *UI, row processRow) string {
+		return row.User
+	}},
+	{name: "UID", description: "owner's user ID", minWidth: 5, value: func(ui *UI, row processRow) string {
+		return row.UID
+	}},
+	{name: "COMMAND", description: "command name, or its command line after a", left: true, fill: true, value: func(ui *UI, row processRow) string {
+		command := ui.scrolledCommand(commandText(row))
+		if row.ThreadOf != "" {
+			command = THREAD_PREFIX + command
+		}
+		if row.member {
+			command = THREAD_PREFIX + command
+		}
+		if row.groupSize > 0 {
+			command = fmt.Sprintf("%s ×%d", command, row.groupSize)
+		}
+		return command
+	}},
+	{name: "STATE", description: "scheduling state", minWidth: 5, left: true, value: func(ui *UI, row processRow) string {
+		return row.State
+	}},
+	{name: "PRIO", description: "kernel priority", minWidth: 5, left: true, value: func(ui *UI, row processRow) string {
+		return row.Priority
+	}},
+	{name: "NICE", description: "nice value", minWidth: 4, value: func(ui *UI, row processRow) string {
+		return row.Nice
+	}},
+	{name: "THREADS", description: "number of threads", value: func(ui *UI, row processRow) string {
+		if row.ThreadOf != "" {
+			return ""
+		}
+		return strconv.Itoa(row.ThreadCount)
+	}},
+	{name: "CPU%", description: "CPU usage, of all cores", minWidth: 6, value: func(ui *UI, row processRow) string {
+		return fmt.Sprintf("%.1f%%", row.CpuUsage)
+	}},
+	{name: "CPU#", description: "CPU the task last ran on", minWidth: 4, value: func(ui *UI, row processRow) string {
+		if row.groupSize > 0 || row.Processor < 0 {
+			return ""
+		}
+		return strconv.Itoa(row.Processor)
+	}},
+	{name: "TIME+", description: "CPU time used since it started", minWidth: 9, value: func(ui *UI, row processRow) string {
+		return formatCpuTime(row.CpuTime)
+	}},
+	{name: "START", description: "when it started", minWidth: 5, value: func(ui *UI, row processRow) string {
+		if row.groupSize > 0 {
+			return ""
+		}
+		return formatStartTime(row.StartedAt, ui.shownAt)
+	}},
+	{name: "TTY", description: "controlling terminal", minWidth: 6, left: true, value: func(ui *UI, row processRow) string {
+		if row.groupSize > 0 {
+			return ""
+		}
+		return valueOrDash(row.TTY)
+	}},
+	{name: "MEM%", description: "resident memory, of all memory", minWidth: 6, value: func(ui *UI, row processRow) string {
+		if row.ThreadOf != "" {
+			return ""
+		}
+		return fmt.Sprintf("%.1f%%", row.MemUsage)
+	}},
+	memoryColumn("VIRT", "virtual memory", func(m proc.ProcessMemory) int64 { return m.Virtual }),
+	memoryColumn("RES", "resident memory", func(m proc.ProcessMemory) int64 { return m.Resident }),
+	memoryColumn("SHR", "resident memory shared with other processes", func(m proc.ProcessMemory) int64 { return m.Shared }),
+	memoryColumn("SWAP", "memory swapped out", func(m proc.ProcessMemory) int64 { return m.Swap }),
+	rollupColumn("PSS", "resident memory with shared pages split between their users", func(m proc.ProcessMemory) int64 { return m.Proportional }),
+	rollupColumn("USS", "memory no other process shares", func(m proc.ProcessMemory) int64 { return m.Unique }),
+	ioColumn("READ", "bytes read from storage", func(io proc.ProcessIO, totals bool) string {
+		if totals {
+			return format.Bytes(io.ReadTotal)
+		}
+		return format.Rate(io.ReadRate)
+	}),
+	ioColumn("WRITE", "bytes written to storage", func(io proc.ProcessIO, totals bool) string {
+		if totals {
+			return format.Bytes(io.WriteTotal)
+		}
+		return format.Rate(io.WriteRate)
+	}),
+	namespaceColumn("NSPID", "PID inside its PID namespace", func(p proc.Process) string { return p.NamespacePID }),
+	namespaceColumn("PIDNS", "PID namespace", func(p proc.Process) string { return p.Namespaces.PID }),
+	namespaceColumn("NETNS", "network namespace", func(p proc.Process) string { return p.Namespaces.Net }),
+	namespaceColumn("MNTNS", "mount namespace", func(p proc.Process) string { return p.Namespaces.Mnt }),
+	{name: "CGROUP", description: "cgroup v2 path", minWidth: 6, maxWidth: CGROUP_COLUMN_MAX_WIDTH, left: true, value: func(ui *UI, row processRow) string {
+		if row.groupSize > 0 {
+			return ""
+		}
+		return valueOrDash(row.Cgroup)
+	}},
+}
+
+// Columns shown until the user picks their own
+var defaultColumns = []string{"PID", "USER", "COMMAND", "STATE", "PRIO", "CPU%", "MEM%", "VIRT", "RES", "SHR", "SWAP", "PSS", "USS", "READ", "WRITE"}
+
+// Columns the N key shows and hides together
+var namespaceColumns = []string{"NSPID", "PIDNS", "NETNS", "MNTNS"}
+
+// Threads share the memory of their process, so they leave it blank
+func memoryColumn(name, description string, bytes func(proc.ProcessMemory) int64) column {
+	return column{name: name, description: description, minWidth: MEMORY_COLUMN_WIDTH, value: func(ui *UI, row processRow) string {
+		if row.ThreadOf != "" {
+			return ""
+		}
+		return format.Bytes(bytes(row.Memory))
+	}}
+}
+
+// rollupColumn shows a value of smaps_rollup, which is only read after S
+func rollupColumn(name, description string, bytes func(proc.ProcessMemory) int64) column {
+	c := memoryColumn(name, description+" (S reads it)", bytes)
+	c.hidden = func(ui *UI) bool {
+		return !proc.SmapsRollupEnabled()
+	}
+
+	memory := c.value
+	c.value = func(ui *UI, row processRow) string {
+		if row.ThreadOf == "" && !row.Memory.HasRollup {
+			return "-"
+		}
+		return memory(ui, row)
+	}
+
+	return c
+}
+
+// Processes owned by other users usually hide their IO counters
+func ioColumn(name, description string, value func(io proc.ProcessIO, totals bool) string) column {
+	return column{
+		name:        name,
+		description: description + " per second, or in total after i",
+		minWidth:    IO_COLUMN_WIDTH,
+		header: func(ui *UI) string {
+			if ui.showIOTotals {
+				return name
+			}
+			return name + "/s"
+		},
+		value: func(ui *UI, row processRow) string {
+			if !row.IO.Readable {
+				return "n/a"
+			}
+			return value(row.IO, ui.showIOTotals)
+		},
+	}
+}
+
+// Group rows and threads have no namespaces of their own to show
+func namespaceColumn(name, description string, value func(proc.Process) string) column {
+	return column{name: name, description: description, minWidth: NAMESPACE_COLUMN_WIDTH, value: func(ui *UI, row processRow) string {
+		if row.groupSize > 0 || row.ThreadOf != "" {
+			return ""
+		}
+		return valueOrDash(value(row.Process))
+	}}
+}
+
+func findColumn(name string) *column {
+	for i := range processColumns {
+		if processColumns[i].name == name {
+			return &processColumns[i]
+		}
+	}
+
+	return nil
+}
+
+// loadColumns takes the columns of the config file, skipping names this
+// version doesn't know
+func loadColumns(names []string) []string {
+	var columns []string
+
+	for _, name := range names {
+		if findColumn(name) != nil && !slices.Contains(columns, name) {
+			columns = append(columns, name)
+		}
+	}
+
+	if len(columns) == 0 {
+		return slices.Clone(defaultColumns)
+	}
+
+	return columns
+}
+
+// shownColumn is a column laid out for the rows on screen
+type shownColumn struct {
+	*column
+	header string
+	width  int
+	cells  []string
+}
+
+// measureColumns finds the widest value of every chosen column over all rows.
+// It runs once per refresh rather than on every draw, as it formats every
+// value of the list.
+func (ui *UI) measureColumns() map[string]int {
+	widths := make(map[string]int, len(ui.settings.Columns))
+
+	for _, name := range ui.settings.Columns {
+		c := findColumn(name)
+		if c.fill || (c.hidden != nil && c.hidden(ui)) {
+			continue
+		}
+
+		for _, row := range ui.processes {
+			widths[name] = max(widths[name], len([]rune(c.value(ui, row))))
+		}
+	}
+
+	return widths
+}
+
+// layoutColumns sizes the chosen columns to their header and the values of
+// every row as of the last refresh, so scrolling doesn't shift them, and
+// leaves out columns from the end that don't fit in width. Cells are kept
+// for the rows from first to end.
+func (ui *UI) layoutColumns(first, end, width int) []shownColumn {
+	var shown []shownColumn
+	used := -1 // no space before the first column
+
+	for _, name := range ui.settings.Columns {
+		c := findColumn(name)
+		if c.hidden != nil && c.hidden(ui) {
+			continue
+		}
+
+		s := shownColumn{column: c, header: c.name, cells: make([]string, end-first)}
+		if c.header != nil {
+			s.header = c.header(ui)
+		}
+
+		// Values may have changed since, e.g. with other units, and the
+		// column widens to those on screen right away
+		s.width = max(c.minWidth, len(s.header), ui.columnWidths[name])
+		for i := first; i < end; i++ {
+			s.cells[i-first] = c.value(ui, ui.processes[i])
+			s.width = max(s.width, len([]rune(s.cells[i-first])))
+		}
+
+		maxWidth := c.maxWidth
+		if maxWidth == 0 {
+			maxWidth = MAX_COLUMN_WIDTH
+		}
+		s.width = min(s.width, max(maxWidth, c.minWidth, len(s.header)))
+
+		if c.fill {
+			s.width = MIN_COMMAND_WIDTH
+		}
+
+		if used+1+s.width > width && len(shown) > 0 {
+			break
+		}
+
+		used += 1 + s.width
+		shown = append(shown, s)
+	}
+
+	// The filling column widens to the rest of the line
+	for i := range shown {
+		if shown[i].fill {
+			shown[i].width += width - used
+		}
+	}
+
+	return shown
+}
+
+// fitColumn pads or cuts value to width
+func fitColumn(value string, width int, left bool) string {
+	if len([]rune(value)) > width {
+		return truncateString(value, width)
+	}
+
+	if left {
+		return fmt.Sprintf("%-*s", width, value)
+	}
+
+	return fmt.Sprintf("%*s", width, value)
+}
+
+// formatCpuTime shows CPU time as top does, minutes:seconds.hundredths,
+// switching to hours and then days for long-running processes
+func formatCpuTime(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%d:%02d.%02d", int(d.Minutes()), int(d.Seconds())%60, int(d.Milliseconds()/10)%100)
+	case d < 100*time.Hour:
+		return fmt.Sprintf("%dh%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
+
+// formatStartTime shows the time of day for processes started today and
+// the date for older ones, as ps does
+func formatStartTime(started, now time.Time) string {
+	switch {
+	case started.YearDay() == now.YearDay() && started.Year() == now.Year():
+		return started.Format("15:04")
+	case started.Year() == now.Year():
+		return started.Format("Jan02")
+	default:
+		return started.Format("2006")
+	}
+}
+
+// chooserColumns lists the shown columns in their order, then the others
+func (ui *UI) chooserColumns() []string {
+	names := slices.Clone(ui.settings.Columns)
+
+	for _, c := range processColumns {
+		if !slices.Contains(names, c.name) {
+			names = append(names, c.name)
+		}
+	}
+
+	return names
+}
+
+// toggleSelectedColumn shows or hides the column selected in the chooser.
+// The last shown column stays, as an empty list would have nothing to show.
+func (ui *UI) toggleSelectedColumn() {
+	names := ui.chooserColumns()
+	if ui.selectedRow >= len(names) {
+		return
+	}
+
+	name := names[ui.selectedRow]
+	idx := slices.Index(ui.settings.Columns, name)
+
+	switch {
+	case idx < 0:
+		ui.settings.Columns = append(ui.settings.Columns, name)
+		ui.selectedRow = len(ui.settings.Columns) - 1
+	case len(ui.settings.Columns) > 1:
+		ui.settings.Columns = slices.Delete(ui.settings.Columns, idx, idx+1)
+	default:
+		return
+	}
+
+	ui.saveSettings()
+}
+
+// moveSelectedColumn moves the selected shown column left or right by delta
+func (ui *UI) moveSelectedColumn(delta int) {
+	idx := ui.selectedRow
+	target := idx + delta
+
+	if idx >= len(ui.settings.Columns) || target < 0 || target >= len(ui.settings.Columns) {
+		return
+	}
+
+	columns := ui.settings.Columns
+	columns[idx], columns[target] = columns[target], columns[idx]
+	ui.selectedRow = target
+
+	ui.saveSettings()
+}
+
+// toggleNamespaceColumns hides the namespace columns when any is shown, and
+// shows all of them otherwise
+func (ui *UI) toggleNamespaceColumns() {
+	shown := slices.ContainsFunc(ui.settings.Columns, func(name string) bool {
+		return slices.Contains(namespaceColumns, name)
+	})
+
+	if shown {
+		ui.settings.Columns = slices.DeleteFunc(ui.settings.Columns, func(name string) bool {
+			return slices.Contains(namespaceColumns, name)
+		})
+		if len(ui.settings.Columns) == 0 {
+			ui.settings.Columns = slices.Clone(defaultColumns)
+		}
+	} else {
+		ui.settings.Columns = append(ui.settings.Columns, namespaceColumns...)
+	}
+
+	ui.saveSettings()
+}
+
+// saveSettings writes the settings to the config file, saying so on the
+// top line when it can't
+func (ui *UI) saveSettings() {
+	if err := config.Save(ui.settings); err != nil {
+		ui.notice = notice{text: "settings not saved: " + err.Error(), until: time.Now().Add(NOTICE_DURATION)}
+	}
+}
+
+func (ui *UI) renderColumnChooser(dim displayDimensions, startY, maxHeight int) {
+	header := fmt.Sprintf("    %-*s %s", COLUMN_NAME_WIDTH, "COLUMN", "SHOWS")
+	emitStr(ui.screen, dim.startWidth, startY, ui.styles.text, header)
+	startY++
+
+	names := ui.chooserColumns()
+	visibleCount := max(0, maxHeight-1) // -1 for header
+	endIdx := min(len(names), ui.scrollOffset+visibleCount)
+
+	for i := ui.scrollOffset; i < endIdx; i++ {
+		c := findColumn(names[i])
+
+		mark := "[ ]"
+		if i < len(ui.settings.Columns) {
+			mark = "[x]"
+		}
+
+		line := fmt.Sprintf("%s %-*s %s", mark, COLUMN_NAME_WIDTH, c.name, c.description)
+
+		style := ui.styles.text
+		if i >= len(ui.settings.Columns) {
+			style = ui.styles.hintText
+		}
+		if i == ui.selectedRow {
+			style = ui.styles.selectedText
+		}
+
+		emitStr(ui.screen, dim.startWidth, startY+(i-ui.scrollOffset), style, truncateString(line, dim.totalWidth))
+	}
+}
@@ -0,0 +1,48 @@
+package application
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Characters Left and Right scroll the command by
+const COMMAND_SCROLL_STEP = 8
+
+// commandText is what the COMMAND column shows for a row: the command line
+// with its program shortened to the file name once command lines are read,
+// the name otherwise, and kernel threads in brackets as ps shows them
+func commandText(row processRow) string {
+	switch {
+	case row.KernelThread:
+		return "[" + row.Command + "]"
+	case len(row.Args) == 0:
+		return row.Command
+	}
+
+	args := append([]string{}, row.Args...)
+
+	// Programs that rewrite their command line put spaces in it; that is
+	// no path to shorten
+	if !strings.Contains(args[0], " ") {
+		args[0] = filepath.Base(args[0])
+	}
+
+	return strings.Join(args, " ")
+}
+
+// scrolledCommand cuts off the part of a command scrolled past on the left
+func (ui *UI) scrolledCommand(command string) string {
+	runes := []rune(command)
+	return string(runes[min(ui.commandScroll, len(runes)):])
+}
+
+// scrollCommand scrolls the commands of the process list horizontally, up to
+// where the longest one still shows its end
+func (ui *UI) scrollCommand(delta int) {
+	longest := 0
+	for _, row := range ui.processes {
+		longest = max(longest, len([]rune(commandText(row))))
+	}
+
+	ui.commandScroll = max(0, min(ui.commandScroll+delta, longest-COMMAND_SCROLL_STEP))
+}
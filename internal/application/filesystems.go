@@ -0,0 +1,86 @@
+package application
+
+import (
+	"fmt"
+
+	"github.com/amirdaraby/titop/internal/alert"
+	"github.com/amirdaraby/titop/internal/format"
+)
+
+const (
+	MOUNT_COLUMN_MIN_WIDTH = 16
+	FS_TYPE_COLUMN_WIDTH   = 8
+
+	// Usage from which a filesystem is flagged as nearly full
+	DISK_WARNING_USAGE = 90.0
+)
+
+// renderFilesystemList draws a row per filesystem with a bar for the space
+// and one for the inodes it uses
+func (ui *UI) renderFilesystemList(dim displayDimensions, startY, maxHeight int) {
+	if len(ui.filesystems) == 0 {
+		emitStr(ui.screen, dim.startWidth, startY, ui.styles.text, "No filesystems found")
+		return
+	}
+
+	usedWidth := 7 + MEMORY_COLUMN_WIDTH*2 + 1 // " 87.4%" + USED/TOTAL
+	inodeWidth := 7                            // " 11.9%"
+	mountWidth := max(dim.totalWidth/4, MOUNT_COLUMN_MIN_WIDTH)
+	barLen := max((dim.totalWidth-mountWidth-FS_TYPE_COLUMN_WIDTH-usedWidth-inodeWidth-4)/2, MIN_BAR_LENGTH)
+
+	usedX := dim.startWidth + mountWidth + 1 + FS_TYPE_COLUMN_WIDTH + 1
+	inodeX := usedX + barLen + usedWidth + 2
+
+	emitStr(ui.screen, dim.startWidth, startY, ui.panelStyle(alert.PANEL_DISK), fmt.Sprintf("%-*s %-*s", mountWidth, "MOUNT", FS_TYPE_COLUMN_WIDTH, "TYPE"))
+	emitStr(ui.screen, usedX, startY, ui.styles.text, "USED")
+	emitStr(ui.screen, inodeX, startY, ui.styles.text, "INODES")
+	startY++
+
+	visibleCount := max(0, maxHeight-1) // -1 for header
+	endIdx := min(len(ui.filesystems), ui.scrollOffset+visibleCount)
+
+	for i := ui.scrollOffset; i < endIdx; i++ {
+		fs := ui.filesystems[i]
+		y := startY + (i - ui.scrollOffset)
+
+		style := ui.styles.text
+		switch {
+		case i == ui.selectedRow:
+			style = ui.styles.selectedText
+		case fs.Usage >= DISK_WARNING_USAGE || fs.InodeUsage >= DISK_WARNING_USAGE:
+			style = ui.styles.warningText
+		}
+
+		name := fmt.Sprintf("%-*s %-*s", mountWidth, truncateString(fs.MountPoint, mountWidth), FS_TYPE_COLUMN_WIDTH, truncateString(fs.Type, FS_TYPE_COLUMN_WIDTH))
+		emitStr(ui.screen, dim.startWidth, y, style, name)
+
+		ui.renderColoredBar(usedX, y, fs.Usage, barLen)
+		used := fmt.Sprintf(" %5.1f%% %*s", fs.Usage, MEMORY_COLUMN_WIDTH*2+1, format.Bytes(fs.Used)+"/"+format.Bytes(fs.Total))
+		emitStr(ui.screen, usedX+barLen, y, style, used)
+
+		// Filesystems that don't count inodes get an empty bar
+		ui.renderColoredBar(inodeX, y, fs.InodeUsage, barLen)
+		inodes := " -"
+		if fs.HasInodes {
+			inodes = fmt.Sprintf(" %5.1f%%", fs.InodeUsage)
+		}
+		emitStr(ui.screen, inodeX+barLen, y, style, inodes)
+	}
+}
+
+// renderFilesystemDetails shows where the selected filesystem comes from
+func (ui *UI) renderFilesystemDetails(dim displayDimensions, y int) {
+	if ui.selectedRow >= len(ui.filesystems) {
+		ui.renderHint(dim, y, "A: show all filesystems  Esc: back")
+		return
+	}
+
+	fs := ui.filesystems[ui.selectedRow]
+
+	details := fmt.Sprintf("%s on %s  available %s", fs.Source, fs.MountPoint, format.Bytes(fs.Available))
+	if fs.HasInodes {
+		details += fmt.Sprintf("  inodes %d/%d", fs.InodesUsed, fs.Inodes)
+	}
+
+	emitStr(ui.screen, dim.startWidth, y, ui.styles.text, truncateString(details+"  A: show all filesystems  Esc: back", dim.totalWidth))
+}
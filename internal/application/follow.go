@@ -0,0 +1,148 @@
+package application
+
+import (
+	"fmt"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/amirdaraby/titop/internal/collect/proc"
+	"golang.org/x/sys/unix"
+)
+
+// How long a notice stays on the top line
+const NOTICE_DURATION = 10 * time.Second
+
+// followState keeps one process selected wherever it moves in the list.
+// The start time tells it apart from a later process reusing its PID.
+type followState struct {
+	pid, command string
+	startedAt    time.Time
+}
+
+type notice struct {
+	text  string
+	until time.Time
+}
+
+// rowKey identifies a row of the process list across refreshes, keeping a
+// reused PID from passing for the process that had it before
+func rowKey(row processRow) string {
+	if row.groupSize > 0 {
+		return "group " + row.Command
+	}
+
+	return row.ID + " " + strconv.FormatInt(row.StartedAt.UnixNano(), 10)
+}
+
+// selectedKey returns the key of the selected process row and the line of
+// the list it is shown on
+func (ui *UI) selectedKey() (string, int) {
+	if ui.view != PROCESS_VIEW || ui.selectedRow >= len(ui.processes) {
+		return "", 0
+	}
+
+	return rowKey(ui.processes[ui.selectedRow]), ui.selectedRow - ui.scrollOffset
+}
+
+// reselect moves the selection back onto the row it was on before a
+// refresh, on the same line of the screen when the list allows it
+func (ui *UI) reselect(key string, line int) {
+	if key == "" {
+		return
+	}
+
+	for i, row := range ui.processes {
+		if rowKey(row) == key {
+			ui.selectedRow = i
+			ui.scrollOffset = max(0, min(i-line, len(ui.processes)-ui.visibleRows()))
+			return
+		}
+	}
+}
+
+// toggleFollow starts following the selected process, or stops following
+func (ui *UI) toggleFollow() {
+	if ui.follow.pid != "" {
+		ui.follow = followState{}
+		return
+	}
+
+	if ui.view != PROCESS_VIEW || ui.selectedRow >= len(ui.processes) || ui.processes[ui.selectedRow].groupSize > 0 {
+		return
+	}
+
+	p := ui.processes[ui.selectedRow]
+	ui.follow = followState{pid: p.ID, command: p.Command, startedAt: p.StartedAt}
+}
+
+// followProcess keeps the followed process selected and in view, and
+// reports when it exits
+func (ui *UI) followProcess() {
+	if ui.follow.pid == "" {
+		return
+	}
+
+	var followed *proc.Process
+	for i := range ui.allProcesses {
+		if ui.allProcesses[i].ID == ui.follow.pid && ui.allProcesses[i].StartedAt.Equal(ui.follow.startedAt) {
+			followed = &ui.allProcesses[i]
+			break
+		}
+	}
+
+	if followed == nil || followed.Exited || followed.State == proc.ZOMBIE_STATE {
+		text := fmt.Sprintf("PID %s %s exited", ui.follow.pid, ui.follow.command)
+		if followed != nil && followed.HasExitCode {
+			text = fmt.Sprintf("PID %s %s %s", ui.follow.pid, ui.follow.command, describeExit(followed.ExitCode))
+		}
+
+		ui.notice = notice{text: text, until: time.Now().Add(NOTICE_DURATION)}
+		ui.follow = followState{}
+		ui.screen.Beep()
+		return
+	}
+
+	if ui.view != PROCESS_VIEW {
+		return
+	}
+
+	// A collapsed group hides the followed process
+	if ui.groupByCommand && !ui.expandedCommands[followed.Command] {
+		ui.expandedCommands[followed.Command] = true
+		ui.refreshRows()
+	}
+
+	for i, row := range ui.processes {
+		if row.ID == ui.follow.pid && row.StartedAt.Equal(ui.follow.startedAt) && row.groupSize == 0 && row.ThreadOf == "" {
+			ui.selectedRow = i
+			ui.scrollIntoView()
+			return
+		}
+	}
+}
+
+// scrollIntoView scrolls the list just enough to show the selected row
+func (ui *UI) scrollIntoView() {
+	visible := ui.visibleRows()
+
+	if ui.selectedRow < ui.scrollOffset {
+		ui.scrollOffset = ui.selectedRow
+	} else if visible > 0 && ui.selectedRow >= ui.scrollOffset+visible {
+		ui.scrollOffset = ui.selectedRow - visible + 1
+	}
+}
+
+// describeExit reads a wait status the way a shell reports it
+func describeExit(code int) string {
+	status := syscall.WaitStatus(code)
+
+	switch {
+	case status.Signaled() && status.CoreDump():
+		return fmt.Sprintf("was killed by %s (core dumped)", unix.SignalName(status.Signal()))
+	case status.Signaled():
+		return fmt.Sprintf("was killed by %s", unix.SignalName(status.Signal()))
+	}
+
+	return fmt.Sprintf("exited with status %d", status.ExitStatus())
+}
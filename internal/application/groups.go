@@ -0,0 +1,141 @@
+package application
+
+import (
+	"fmt"
+
+	"github.com/amirdaraby/titop/internal/collect/proc"
+	"github.com/amirdaraby/titop/internal/format"
+)
+
+const GROUP_NAME_MIN_WIDTH = 16
+
+// processRow is a row of the process list: a process, one of its threads, or
+// in the grouped mode the summary of every process running the same command
+type processRow struct {
+	proc.Process
+	groupSize int  // processes summed into this row; 0 for a single process
+	member    bool // listed under its expanded group
+}
+
+// buildProcessRows lists processes, grouping them by command when asked to.
+// Threads follow their process while the thread view is enabled.
+func (ui *UI) buildProcessRows(processes []proc.Process) []processRow {
+	if !ui.groupByCommand {
+		return appendProcessRows(nil, processes, false)
+	}
+
+	var rows []processRow
+
+	groups := proc.GroupBy(processes, func(p proc.Process) string {
+		return p.Command
+	})
+
+	for _, group := range groups {
+		if len(group.Processes) == 1 {
+			rows = appendProcessRows(rows, group.Processes, false)
+			continue
+		}
+
+		rows = append(rows, processRow{Process: group.Summary(), groupSize: len(group.Processes)})
+
+		if ui.expandedCommands[group.Name] {
+			rows = appendProcessRows(rows, group.Processes, true)
+		}
+	}
+
+	return rows
+}
+
+func appendProcessRows(rows []processRow, processes []proc.Process, member bool) []processRow {
+	threads := proc.ThreadsEnabled()
+
+	for _, p := range processes {
+		rows = append(rows, processRow{Process: p, member: member})
+
+		if threads {
+			for _, t := range p.Threads {
+				rows = append(rows, processRow{Process: t, member: member})
+			}
+		}
+	}
+
+	return rows
+}
+
+// toggleGroup expands or collapses the selected group row
+func (ui *UI) toggleGroup() {
+	if ui.selectedRow >= len(ui.processes) || ui.processes[ui.selectedRow].groupSize == 0 {
+		return
+	}
+
+	command := ui.processes[ui.selectedRow].Command
+	ui.expandedCommands[command] = !ui.expandedCommands[command]
+	ui.refreshRows()
+}
+
+// groupListColumns describes what a grouped view shows besides the summed usage
+type groupListColumns struct {
+	nameHeader string
+	label      func(proc.Group) string // defaults to the group name
+	limit      func(proc.Group) string // adds a LIMIT column when set
+}
+
+// renderGroupList draws the rows of a grouped view, one group per row
+func (ui *UI) renderGroupList(dim displayDimensions, startY, maxHeight int, columns groupListColumns) {
+	otherColumnsWidth := 6 + 7 + 7 + MEMORY_COLUMN_WIDTH + IO_COLUMN_WIDTH*2 // PROCS + CPU% + MEM% + RES + READ/s + WRITE/s
+	if columns.limit != nil {
+		otherColumnsWidth += MEMORY_COLUMN_WIDTH + 1
+	}
+	nameWidth := max(dim.totalWidth-otherColumnsWidth-6, GROUP_NAME_MIN_WIDTH)
+
+	limitHeader := ""
+	if columns.limit != nil {
+		limitHeader = rightAligned([]string{"LIMIT"}, MEMORY_COLUMN_WIDTH)
+	}
+
+	header := fmt.Sprintf("%-*s %6s %7s %7s %*s%s %*s %*s",
+		nameWidth, columns.nameHeader, "PROCS", "CPU%", "MEM%",
+		MEMORY_COLUMN_WIDTH, "RES", limitHeader, IO_COLUMN_WIDTH, "READ/s", IO_COLUMN_WIDTH, "WRITE/s")
+	emitStr(ui.screen, dim.startWidth, startY, ui.styles.text, header)
+	startY++
+
+	visibleCount := max(0, maxHeight-1) // -1 for header
+	endIdx := min(len(ui.groups), ui.scrollOffset+visibleCount)
+
+	for i := ui.scrollOffset; i < endIdx; i++ {
+		group := ui.groups[i]
+
+		label := group.Name
+		if columns.label != nil {
+			label = columns.label(group)
+		}
+
+		limit := ""
+		if columns.limit != nil {
+			limit = rightAligned([]string{columns.limit(group)}, MEMORY_COLUMN_WIDTH)
+		}
+
+		line := fmt.Sprintf("%-*s %6d %6.1f%% %6.1f%% %*s%s %*s %*s",
+			nameWidth, truncateString(label, nameWidth),
+			len(group.Processes),
+			group.CpuUsage,
+			group.MemUsage,
+			MEMORY_COLUMN_WIDTH, format.Bytes(group.Resident),
+			limit,
+			IO_COLUMN_WIDTH, format.Rate(group.ReadRate),
+			IO_COLUMN_WIDTH, format.Rate(group.WriteRate),
+		)
+
+		style := ui.styles.text
+		if i == ui.selectedRow {
+			style = ui.styles.selectedText
+		}
+
+		emitStr(ui.screen, dim.startWidth, startY+(i-ui.scrollOffset), style, line)
+	}
+}
+
+// renderHint shows which keys apply to the current view
+func (ui *UI) renderHint(dim displayDimensions, y int, hint string) {
+	emitStr(ui.screen, dim.startWidth, y, ui.styles.hintText, hint)
+}
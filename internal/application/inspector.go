@@ -0,0 +1,379 @@
+package application
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/amirdaraby/titop/internal/collect/sock"
+	"github.com/amirdaraby/titop/internal/format"
+	"github.com/amirdaraby/titop/internal/inspect"
+	"github.com/gdamore/tcell/v2"
+)
+
+type inspectorTab int
+
+const (
+	FILES_TAB inspectorTab = iota
+	MEMORY_TAB
+	ENVIRON_TAB
+	LIMITS_TAB
+)
+
+var inspectorTabNames = []string{"Files", "Memory", "Environ", "Limits"}
+
+// Share of a limit used from which it gets flagged
+const LIMIT_WARNING_PERCENT = 80
+
+// Headers of the memory map columns, in the order of inspect.MappingSort
+var mappingSortHeaders = []string{"RSS", "PSS", "SWAP", "DIRTY", "SIZE"}
+
+// inspector holds the details of one process, read when it is opened and
+// again on every refresh while it stays open
+type inspector struct {
+	pid, command string
+	tab          inspectorTab
+	header       string
+	lines        []string
+	err          error
+	mappingSort  inspect.MappingSort
+
+	// Narrows the environment down; typed after pressing '/'
+	environFilter string
+	editingFilter bool
+
+	// Where the process list was when the inspector was opened
+	returnRow, returnOffset int
+}
+
+func (ui *UI) openInspector() {
+	if ui.selectedRow >= len(ui.processes) || ui.processes[ui.selectedRow].groupSize > 0 {
+		return
+	}
+
+	p := ui.processes[ui.selectedRow]
+
+	ui.inspector = inspector{
+		pid:          p.ID,
+		command:      p.Command,
+		returnRow:    ui.selectedRow,
+		returnOffset: ui.scrollOffset,
+	}
+	ui.setView(INSPECT_VIEW)
+	ui.refreshInspector()
+}
+
+func (ui *UI) closeInspector() {
+	ui.setView(PROCESS_VIEW)
+	ui.selectedRow = min(ui.inspector.returnRow, max(0, ui.rowCount()-1))
+	ui.scrollOffset = ui.inspector.returnOffset
+}
+
+func (ui *UI) nextInspectorTab() {
+	ui.inspector.tab = (ui.inspector.tab + 1) % inspectorTab(len(inspectorTabNames))
+	ui.inspector.header, ui.inspector.lines, ui.inspector.err = "", nil, nil
+	ui.selectedRow = 0
+	ui.scrollOffset = 0
+	ui.refreshInspector()
+}
+
+// refreshInspector reads the current tab of the inspected process again.
+// Open files and memory maps can take the kernel long to list, so those tabs
+// are read in the background.
+func (ui *UI) refreshInspector() {
+	in := &ui.inspector
+	pid, by := in.pid, in.mappingSort
+
+	switch in.tab {
+	case FILES_TAB:
+		ui.readInspectorTab(func() (string, []string, error) {
+			return openFilesLines(pid)
+		})
+		return
+	case MEMORY_TAB:
+		ui.readInspectorTab(func() (string, []string, error) {
+			return memoryMapLines(pid, by)
+		})
+		return
+	case ENVIRON_TAB:
+		in.header, in.lines, in.err = environLines(in.pid, in.environFilter)
+	case LIMITS_TAB:
+		in.header, in.lines, in.err = limitsLines(in.pid, ui.userThreads)
+	}
+
+	ui.selectedRow = max(0, min(ui.selectedRow, len(in.lines)-1))
+}
+
+// readInspectorTab runs read off the event loop and shows what it returns,
+// unless another process, tab or sort was picked in the meantime
+func (ui *UI) readInspectorTab(read func() (string, []string, error)) {
+	if ui.readingInspector {
+		ui.rereadInspector = true
+		return
+	}
+	ui.readingInspector = true
+
+	in := ui.inspector
+
+	ui.runInBackground(func() message {
+		header, lines, err := read()
+
+		return func(ui *UI) {
+			ui.readingInspector = false
+			current := &ui.inspector
+
+			if ui.view != INSPECT_VIEW {
+				ui.rereadInspector = false
+				return
+			}
+
+			if current.pid == in.pid && current.tab == in.tab && current.mappingSort == in.mappingSort {
+				current.header, current.lines, current.err = header, lines, err
+				ui.selectedRow = max(0, min(ui.selectedRow, len(current.lines)-1))
+			}
+
+			if ui.rereadInspector {
+				ui.rereadInspector = false
+				ui.refreshInspector()
+			}
+		}
+	}, func(ui *UI) {
+		ui.readingInspector = false
+		ui.rereadInspector = false
+	})
+}
+
+func openFilesLines(pid string) (string, []string, error) {
+	files, err := inspect.OpenFiles(pid)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	header := fmt.Sprintf("%5s  %-4s  %s", "FD", "TYPE", "TARGET")
+	lines := make([]string, 0, len(files))
+
+	for _, f := range files {
+		target := f.Target
+		if f.Socket != nil {
+			target = describeSocket(*f.Socket)
+		}
+
+		lines = append(lines, fmt.Sprintf("%5d  %-4s  %s", f.FD, f.Type, target))
+	}
+
+	return header, lines, nil
+}
+
+// cycleMappingSort sorts the memory maps by the next column
+func (ui *UI) cycleMappingSort() {
+	if ui.inspector.tab != MEMORY_TAB {
+		return
+	}
+
+	ui.inspector.mappingSort = (ui.inspector.mappingSort + 1) % inspect.MAPPING_SORT_COUNT
+	ui.selectedRow = 0
+	ui.scrollOffset = 0
+	ui.refreshInspector()
+}
+
+func memoryMapLines(pid string, by inspect.MappingSort) (string, []string, error) {
+	mappings, err := inspect.MemoryMaps(pid, by)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The column mappings are sorted by is marked
+	headers := make([]string, len(mappingSortHeaders))
+	for i, h := range mappingSortHeaders {
+		if inspect.MappingSort(i) == by {
+			h = "*" + h
+		}
+		headers[i] = h
+	}
+
+	header := fmt.Sprintf("%s  %-7s  %s", rightAligned(headers, MEMORY_COLUMN_WIDTH), "KIND", "MAPPING")
+	lines := make([]string, 0, len(mappings))
+
+	for _, m := range mappings {
+		values := []string{
+			format.Bytes(m.Resident),
+			format.Bytes(m.Proportional),
+			format.Bytes(m.Swap),
+			format.Bytes(m.Dirty),
+			format.Bytes(m.Size),
+		}
+
+		name := m.Name
+		if m.Regions > 1 {
+			name = fmt.Sprintf("%s ×%d", name, m.Regions)
+		}
+
+		lines = append(lines, fmt.Sprintf("%s  %-7s  %s", rightAligned(values, MEMORY_COLUMN_WIDTH), m.Kind, name))
+	}
+
+	return header, lines, nil
+}
+
+// editEnvironFilter handles a key while the environment filter is typed in.
+// Enter keeps the filter, Esc drops it.
+func (ui *UI) editEnvironFilter(ev *tcell.EventKey) {
+	in := &ui.inspector
+
+	switch ev.Key() {
+	case tcell.KeyEnter:
+		in.editingFilter = false
+	case tcell.KeyEscape:
+		in.editingFilter = false
+		in.environFilter = ""
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(in.environFilter) > 0 {
+			_, size := utf8.DecodeLastRuneInString(in.environFilter)
+			in.environFilter = in.environFilter[:len(in.environFilter)-size]
+		}
+	case tcell.KeyRune:
+		in.environFilter += string(ev.Rune())
+	}
+
+	ui.selectedRow = 0
+	ui.scrollOffset = 0
+	ui.refreshInspector()
+}
+
+func environLines(pid, filter string) (string, []string, error) {
+	variables, err := inspect.Environment(pid, filter)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	header := "VARIABLE"
+	if filter != "" {
+		header = fmt.Sprintf("VARIABLE  (filter: %s)", filter)
+	}
+
+	lines := make([]string, 0, len(variables))
+
+	for _, v := range variables {
+		lines = append(lines, v.Key+"="+v.Value)
+	}
+
+	return header, lines, nil
+}
+
+// userThreads counts the threads of a user's processes from the last sample
+func (ui *UI) userThreads(uid string) int64 {
+	var threads int64
+
+	for _, p := range ui.allProcesses {
+		if p.UID == uid && !p.Exited {
+			threads += int64(p.ThreadCount)
+		}
+	}
+
+	return threads
+}
+
+func limitsLines(pid string, userThreads func(uid string) int64) (string, []string, error) {
+	limits, err := inspect.Limits(pid, userThreads)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	header := fmt.Sprintf("%-22s%s  %5s  %s", "LIMIT", rightAligned([]string{"USED", "SOFT", "HARD"}, MEMORY_COLUMN_WIDTH), "USE%", "USED BY")
+	lines := make([]string, 0, len(limits))
+
+	for _, l := range limits {
+		used, percent := "-", "-"
+
+		if l.HasUsage {
+			used = limitValue(l.Used, l.Unit)
+		}
+		if l.HasUsage && l.Soft > 0 {
+			usage := float64(l.Used) / float64(l.Soft) * 100
+			percent = fmt.Sprintf("%.0f%%", usage)
+
+			if usage >= LIMIT_WARNING_PERCENT {
+				percent = "!" + percent
+			}
+		}
+
+		values := []string{used, limitValue(l.Soft, l.Unit), limitValue(l.Hard, l.Unit)}
+		lines = append(lines, fmt.Sprintf("%-22s%s  %5s  %s", truncateString(l.Name, 22), rightAligned(values, MEMORY_COLUMN_WIDTH), percent, l.UsageLabel))
+	}
+
+	return header, lines, nil
+}
+
+func limitValue(value int64, unit string) string {
+	switch {
+	case value == inspect.UNLIMITED:
+		return "unlimited"
+	case unit == "bytes":
+		return format.Bytes(value)
+	}
+
+	return fmt.Sprint(value)
+}
+
+func describeSocket(s sock.Socket) string {
+	if s.Protocol == "unix" {
+		path := s.Local
+		if path == "" {
+			path = "(unnamed)"
+		}
+
+		return fmt.Sprintf("UNIX %s %s", path, s.State)
+	}
+
+	protocol := strings.ToUpper(s.Protocol)
+
+	if s.Listening {
+		return fmt.Sprintf("%s %s %s", protocol, s.Local, s.State)
+	}
+
+	return fmt.Sprintf("%s %s -> %s %s", protocol, s.Local, s.Remote, s.State)
+}
+
+func (ui *UI) renderInspector(dim displayDimensions, startY, maxHeight int) {
+	in := ui.inspector
+
+	// Tab bar, the current tab in brackets
+	var tabs []string
+	for i, name := range inspectorTabNames {
+		if inspectorTab(i) == in.tab {
+			name = "[" + name + "]"
+		}
+		tabs = append(tabs, name)
+	}
+
+	title := fmt.Sprintf("PID %s %s  %s", in.pid, in.command, strings.Join(tabs, " "))
+	emitStr(ui.screen, dim.startWidth, startY, ui.styles.text, title)
+	startY++
+
+	if in.err != nil {
+		emitStr(ui.screen, dim.startWidth, startY, ui.styles.text, "Can't inspect this process: "+in.err.Error())
+		return
+	}
+	if in.header == "" && ui.readingInspector {
+		emitStr(ui.screen, dim.startWidth, startY, ui.styles.hintText, "Reading...")
+		return
+	}
+
+	emitStr(ui.screen, dim.startWidth, startY, ui.styles.text, in.header)
+	startY++
+
+	visibleCount := max(0, maxHeight-2) // -2 for title and header
+	endIdx := min(len(in.lines), ui.scrollOffset+visibleCount)
+
+	for i := ui.scrollOffset; i < endIdx; i++ {
+		style := ui.styles.text
+		if i == ui.selectedRow {
+			style = ui.styles.selectedText
+		}
+
+		emitStr(ui.screen, dim.startWidth, startY+(i-ui.scrollOffset), style, truncateString(in.lines[i], dim.totalWidth))
+	}
+}
@@ -0,0 +1,252 @@
+package application
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/amirdaraby/titop/internal/alert"
+	"github.com/amirdaraby/titop/internal/config"
+	"github.com/amirdaraby/titop/internal/shared"
+)
+
+const (
+	DEFAULT_METER_SHARE = 50 // percent of the screen height
+	MIN_METER_SHARE     = 10
+	MAX_METER_SHARE     = 80
+	METER_SHARE_STEP    = 10
+	MIN_METER_WIDTH     = 16 // fits "CPU127 (100.0%)"
+	MEMORY_PANEL_HEIGHT = 2
+	MIN_LIST_HEIGHT     = 5 // the panels never squeeze the list below this
+
+	// Below this size only a one-line summary is shown above the list
+	MIN_FULL_WIDTH  = 50
+	MIN_FULL_HEIGHT = 14
+)
+
+// How the CPU panel draws the cores; a number is how many meters go on a row
+const (
+	CPU_METERS_AUTO    = "auto"
+	CPU_METERS_HEATMAP = "heatmap"
+)
+
+// The m key cycles through these
+var cpuMeterModes = []string{CPU_METERS_AUTO, "2", "4", "8", CPU_METERS_HEATMAP}
+
+// Meters of the auto mode, tried from the most readable
+var autoCoresPerRow = []int{2, 4, 8}
+
+// Heatmap cells fill up with the usage of their core
+var heatmapLevels = []rune(" ▁▂▃▄▅▆▇█")
+
+// screenLayout places the panels and the list on the screen
+type screenLayout struct {
+	minimal bool // a summary line instead of panels
+
+	cpuY        int
+	cpuRows     int // rows of meters or heatmap cells; 0 when the panel is hidden
+	coresPerRow int // 0 for the heatmap
+
+	memoryY int // -1 when the panel is hidden
+
+	listY      int
+	listHeight int // lines of the current view, header included
+}
+
+// loadLayout fills in what the config file leaves out or gets wrong
+func loadLayout(layout config.Layout) config.Layout {
+	if !slices.Contains(cpuMeterModes, layout.CPUMeters) {
+		layout.CPUMeters = CPU_METERS_AUTO
+	}
+
+	if layout.MeterShare == 0 {
+		layout.MeterShare = DEFAULT_METER_SHARE
+	}
+	layout.MeterShare = max(MIN_METER_SHARE, min(layout.MeterShare, MAX_METER_SHARE))
+
+	return layout
+}
+
+// computeLayout stacks the panels that are shown and fit, and gives the
+// rest of the screen to the list. The last line is kept for details.
+func (ui *UI) computeLayout(width, height int) screenLayout {
+	l := screenLayout{memoryY: -1}
+
+	if width < MIN_FULL_WIDTH || height < MIN_FULL_HEIGHT {
+		l.minimal = true
+		l.listY = 1
+		l.listHeight = max(0, height-2)
+		return l
+	}
+
+	settings := ui.settings.Layout
+	y := 0
+
+	// The panels share the part of the screen the split gives them
+	budget := height * settings.MeterShare / 100
+	if !settings.HideMemory {
+		budget -= MEMORY_PANEL_HEIGHT + 1
+	}
+
+	if !settings.HideCPU && len(ui.cpu.Cores) > 0 {
+		// However the cores are drawn, the list keeps some room
+		room := height - MIN_LIST_HEIGHT - 1
+		if !settings.HideMemory {
+			room -= MEMORY_PANEL_HEIGHT + 1
+		}
+
+		l.cpuY = y
+		l.coresPerRow, l.cpuRows = ui.cpuMeterLayout(ui.calculateDimensions(width).totalWidth, budget, room)
+		y += cpuPanelHeight(l) + 1
+	}
+
+	if !settings.HideMemory {
+		l.memoryY = y
+		y += MEMORY_PANEL_HEIGHT + 1
+	}
+
+	// The top line is taken by the refresh rate even without panels
+	l.listY = max(y, 1)
+	l.listHeight = max(0, height-l.listY-1)
+
+	return l
+}
+
+// cpuMeterLayout picks how many meters go on a row, 0 for the heatmap, and
+// how many rows are drawn within budget lines. Auto mode takes the fewest
+// meters per row that fit and falls back to the heatmap; a chosen number of
+// meters narrower than the screen allows is halved until they fit.
+func (ui *UI) cpuMeterLayout(width, budget, room int) (coresPerRow, rows int) {
+	cores := len(ui.cpu.Cores)
+	mode := ui.settings.Layout.CPUMeters
+
+	switch mode {
+	case CPU_METERS_AUTO:
+		coresPerRow = 0
+		for _, perRow := range autoCoresPerRow {
+			if meterWidth(width, perRow) < MIN_METER_WIDTH {
+				break
+			}
+			if 2*ceilDiv(cores, perRow) <= budget {
+				coresPerRow = perRow
+				break
+			}
+		}
+	case CPU_METERS_HEATMAP:
+		coresPerRow = 0
+	default:
+		coresPerRow, _ = strconv.Atoi(mode)
+		for coresPerRow > 0 && meterWidth(width, coresPerRow) < MIN_METER_WIDTH {
+			coresPerRow /= 2
+		}
+	}
+
+	// Cores that don't fit are counted rather than drawn
+	if coresPerRow == 0 {
+		// A title line above the cells
+		return 0, max(1, min(ceilDiv(cores, heatmapCellsPerRow(width)), budget-1, room-1))
+	}
+
+	return coresPerRow, max(1, min(ceilDiv(cores, coresPerRow), budget/2, room/2))
+}
+
+// hiddenCores counts the cores left out of the rows of the panel
+func (ui *UI) hiddenCores(perRow, rows int) int {
+	return max(0, len(ui.cpu.Cores)-perRow*rows)
+}
+
+func cpuPanelHeight(l screenLayout) int {
+	if l.cpuRows == 0 {
+		return 0
+	}
+
+	if l.coresPerRow == 0 {
+		return l.cpuRows + 1
+	}
+
+	return l.cpuRows * 2
+}
+
+func meterWidth(width, perRow int) int {
+	return (width - (perRow-1)*GAP_BETWEEN_BOXES) / perRow
+}
+
+// Cells are a character wide with a space between them
+func heatmapCellsPerRow(width int) int {
+	return max(1, (width+1)/2)
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// renderCPUHeatmap draws every core as a cell coloured and filled by its usage
+func (ui *UI) renderCPUHeatmap(dim displayDimensions, l screenLayout) {
+	title := fmt.Sprintf("CPU (%.1f%%) %d cores", ui.cpu.Usage, len(ui.cpu.Cores))
+	emitStr(ui.screen, dim.startWidth, l.cpuY, ui.panelStyle(alert.PANEL_CPU), title)
+
+	perRow := heatmapCellsPerRow(dim.totalWidth)
+
+	if hidden := ui.hiddenCores(perRow, l.cpuRows); hidden > 0 {
+		emitStr(ui.screen, dim.startWidth+len(title)+2, l.cpuY, ui.styles.hintText, fmt.Sprintf("+%d cores", hidden))
+	}
+
+	for idx, core := range ui.cpu.Cores {
+		row, col := idx/perRow, idx%perRow
+		if row >= l.cpuRows {
+			break
+		}
+
+		level := int(core.Usage / 100 * float32(len(heatmapLevels)-1))
+		level = max(0, min(level, len(heatmapLevels)-1))
+
+		x := dim.startWidth + col*2
+		ui.screen.SetContent(x, l.cpuY+1+row, heatmapLevels[level], nil, getBarStyle(core.Usage))
+	}
+}
+
+// renderSummary puts CPU, memory and swap usage on the top line when the
+// terminal is too small for the panels, and returns the column it ends at
+func (ui *UI) renderSummary(x, y int) int {
+	parts := []string{fmt.Sprintf("CPU %.1f%%", ui.cpu.Usage), fmt.Sprintf("MEM %.1f%%", ui.mem.Usage)}
+	panels := []string{alert.PANEL_CPU, alert.PANEL_MEMORY}
+
+	if ui.mem.Swap != nil {
+		parts = append(parts, fmt.Sprintf("SWP %.1f%%", ui.mem.Swap.Usage))
+		panels = append(panels, alert.PANEL_MEMORY)
+	}
+
+	for i, text := range parts {
+		emitStr(ui.screen, x, y, ui.panelStyle(panels[i]), text)
+		x += len(text) + 2
+	}
+
+	rate := fmt.Sprintf("%dms", shared.GetRefreshRate())
+	emitStr(ui.screen, x, y, ui.styles.hintText, rate)
+
+	return x + len(rate)
+}
+
+// cycleCPUMeters switches to the next way of drawing the cores
+func (ui *UI) cycleCPUMeters() {
+	idx := slices.Index(cpuMeterModes, ui.settings.Layout.CPUMeters)
+	ui.settings.Layout.CPUMeters = cpuMeterModes[(idx+1)%len(cpuMeterModes)]
+	ui.saveSettings()
+}
+
+// resizePanels moves the split between the panels and the list by delta percent
+func (ui *UI) resizePanels(delta int) {
+	share := ui.settings.Layout.MeterShare + delta
+	ui.settings.Layout.MeterShare = max(MIN_METER_SHARE, min(share, MAX_METER_SHARE))
+	ui.saveSettings()
+}
+
+func (ui *UI) toggleCPUPanel() {
+	ui.settings.Layout.HideCPU = !ui.settings.Layout.HideCPU
+	ui.saveSettings()
+}
+
+func (ui *UI) toggleMemoryPanel() {
+	ui.settings.Layout.HideMemory = !ui.settings.Layout.HideMemory
+	ui.saveSettings()
+}
@@ -0,0 +1,41 @@
+package application
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/amirdaraby/titop/internal/collect"
+)
+
+// pauseState freezes the display while collection goes on
+type pauseState struct {
+	paused  bool
+	pending collect.Snapshot // latest snapshot held back
+	skipped int              // snapshots held back so far
+}
+
+// togglePause freezes the display, or catches up with the latest snapshot
+func (ui *UI) togglePause() {
+	if !ui.pause.paused {
+		ui.pause = pauseState{paused: true}
+		return
+	}
+
+	pending := ui.pause.pending
+	ui.pause = pauseState{}
+
+	if pending != nil {
+		ui.show(pending)
+	}
+}
+
+// pauseIndicator tells how far behind the frozen display is
+func (ui *UI) pauseIndicator() string {
+	behind := time.Since(ui.shownAt).Round(time.Second)
+
+	if ui.pause.skipped == 1 {
+		return fmt.Sprintf("PAUSED %s behind (1 sample)", behind)
+	}
+
+	return fmt.Sprintf("PAUSED %s behind (%d samples)", behind, ui.pause.skipped)
+}
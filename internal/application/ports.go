@@ -0,0 +1,130 @@
+package application
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amirdaraby/titop/internal/collect/sock"
+	"github.com/amirdaraby/titop/internal/users"
+)
+
+const (
+	PROTOCOL_COLUMN_WIDTH = 5
+	ADDRESS_COLUMN_WIDTH  = 40
+)
+
+// refreshPorts lists the listening sockets again; processes may have opened
+// or closed them since the last refresh. Finding their owners reads the fds
+// of every process, so it runs in the background, one scan at a time.
+func (ui *UI) refreshPorts() {
+	if ui.scanningPorts {
+		return
+	}
+	ui.scanningPorts = true
+
+	ui.runInBackground(func() message {
+		listeners := sock.Listening()
+
+		return func(ui *UI) {
+			ui.scanningPorts = false
+
+			if ui.view == PORTS_VIEW {
+				ui.listeners = listeners
+				ui.selectedRow = max(0, min(ui.selectedRow, len(ui.listeners)-1))
+			}
+		}
+	}, func(ui *UI) {
+		ui.scanningPorts = false
+	})
+}
+
+// openSelectedPort jumps to the first process holding the selected socket
+func (ui *UI) openSelectedPort() {
+	if ui.selectedRow >= len(ui.listeners) || len(ui.listeners[ui.selectedRow].PIDs) == 0 {
+		return
+	}
+
+	ui.selectPID(ui.listeners[ui.selectedRow].PIDs[0])
+}
+
+// selectPID shows the whole process list with the given process selected,
+// expanding its group in the grouped mode
+func (ui *UI) selectPID(pid string) {
+	ui.filter = nil
+
+	if ui.groupByCommand {
+		for _, p := range ui.allProcesses {
+			if p.ID == pid {
+				ui.expandedCommands[p.Command] = true
+				break
+			}
+		}
+	}
+
+	ui.setView(PROCESS_VIEW)
+
+	for i, row := range ui.processes {
+		if row.ID == pid && row.groupSize == 0 && row.ThreadOf == "" {
+			ui.selectedRow = i
+			ui.scrollOffset = max(0, i-ui.visibleRows()/2)
+			return
+		}
+	}
+}
+
+func (ui *UI) renderPortList(dim displayDimensions, startY, maxHeight int) {
+	if len(ui.listeners) == 0 && ui.scanningPorts {
+		emitStr(ui.screen, dim.startWidth, startY, ui.styles.hintText, "Looking for listening sockets...")
+		return
+	}
+	if len(ui.listeners) == 0 {
+		emitStr(ui.screen, dim.startWidth, startY, ui.styles.text, "No listening sockets found")
+		return
+	}
+
+	commands := make(map[string]string, len(ui.allProcesses))
+	for _, p := range ui.allProcesses {
+		commands[p.ID] = p.Command
+	}
+
+	otherColumnsWidth := PROTOCOL_COLUMN_WIDTH + 1 + ADDRESS_COLUMN_WIDTH + 1 + 7 + 1 + USER_COLUMN_WIDTH + 1 // PROTO + ADDRESS + STATE + USER
+	commandWidth := max(dim.totalWidth-otherColumnsWidth-7, MIN_COMMAND_WIDTH)
+
+	header := fmt.Sprintf("%-*s %-*s %-7s %-*s %-*s",
+		PROTOCOL_COLUMN_WIDTH, "PROTO", ADDRESS_COLUMN_WIDTH, "ADDRESS", "STATE",
+		USER_COLUMN_WIDTH, "USER", commandWidth, "PID/COMMAND")
+	emitStr(ui.screen, dim.startWidth, startY, ui.styles.text, header)
+	startY++
+
+	visibleCount := max(0, maxHeight-1) // -1 for header
+	endIdx := min(len(ui.listeners), ui.scrollOffset+visibleCount)
+
+	for i := ui.scrollOffset; i < endIdx; i++ {
+		l := ui.listeners[i]
+
+		// Sockets of processes we may not inspect have no known owner
+		owners := "-"
+		if len(l.PIDs) > 0 {
+			described := make([]string, len(l.PIDs))
+			for j, pid := range l.PIDs {
+				described[j] = pid + "/" + valueOrDash(commands[pid])
+			}
+			owners = strings.Join(described, ", ")
+		}
+
+		line := fmt.Sprintf("%-*s %-*s %-7s %-*s %s",
+			PROTOCOL_COLUMN_WIDTH, l.Protocol,
+			ADDRESS_COLUMN_WIDTH, truncateString(l.Local, ADDRESS_COLUMN_WIDTH),
+			l.State,
+			USER_COLUMN_WIDTH, truncateString(users.Lookup(l.UID), USER_COLUMN_WIDTH),
+			truncateString(owners, commandWidth),
+		)
+
+		style := ui.styles.text
+		if i == ui.selectedRow {
+			style = ui.styles.selectedText
+		}
+
+		emitStr(ui.screen, dim.startWidth, startY+(i-ui.scrollOffset), style, line)
+	}
+}
@@ -3,12 +3,20 @@ package application
 import (
 	"context"
 	"fmt"
-	"os"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/amirdaraby/titop/internal/alert"
+	"github.com/amirdaraby/titop/internal/collect"
+	"github.com/amirdaraby/titop/internal/collect/cgroup"
 	"github.com/amirdaraby/titop/internal/collect/cpu"
+	"github.com/amirdaraby/titop/internal/collect/disk"
 	"github.com/amirdaraby/titop/internal/collect/mem"
 	"github.com/amirdaraby/titop/internal/collect/proc"
+	"github.com/amirdaraby/titop/internal/collect/sock"
+	"github.com/amirdaraby/titop/internal/config"
+	"github.com/amirdaraby/titop/internal/format"
 	"github.com/amirdaraby/titop/internal/shared"
 	"github.com/gdamore/tcell/v2"
 	"github.com/mattn/go-runewidth"
@@ -20,28 +28,97 @@ const (
 	USAGE_MAX_LEN               = 8
 	MIN_BAR_LENGTH              = 10
 	INTERNAL_PADDING            = 1
-	GAP_BETWEEN_BOXES           = 1 // Reduced from 2 to 1
-	CORES_PER_ROW               = 2
+	GAP_BETWEEN_BOXES           = 1  // Reduced from 2 to 1
+	MEMORY_COLUMN_WIDTH         = 9  // fits "1023.9MiB"
+	IO_COLUMN_WIDTH             = 11 // fits "1023.9MiB/s"
+	NAMESPACE_COLUMN_WIDTH      = 10 // fits a namespace inode number
+	MIN_COMMAND_WIDTH           = 8
+	USER_COLUMN_WIDTH           = 10
+	THREAD_PREFIX               = " └ "
+	GROUP_COLLAPSED_MARKER      = "[+]"
+	GROUP_EXPANDED_MARKER       = "[-]"
 
 	// Usage thresholds
 	LOW_USAGE_THRESHOLD  = 30.0
 	HIGH_USAGE_THRESHOLD = 70.0
 )
 
+type viewMode int
+
+const (
+	PROCESS_VIEW viewMode = iota
+	USER_VIEW
+	CGROUP_VIEW
+	SERVICE_VIEW
+	INSPECT_VIEW
+	PORTS_VIEW
+	FILESYSTEM_VIEW
+	COLUMNS_VIEW
+)
+
+// processFilter narrows the process list down after drilling into a row of another view
+type processFilter struct {
+	label string   // shown next to the details of the selected process
+	from  viewMode // view to go back to
+	keep  func(proc.Process) bool
+
+	// Set when filtering by a namespace of a reference process
+	namespaceKind string
+	namespaces    proc.Namespaces
+}
+
+// Namespace kinds the namespace filter cycles through
+var namespaceKinds = []string{"pid", "net", "mnt"}
+
 type UI struct {
-	screen          tcell.Screen
-	styles          uiStyles
-	cpu             cpu.CPU
-	mem             mem.Memory
-	processes       []proc.Process
-	selectedProcess int
-	scrollOffset    int
+	screen       tcell.Screen
+	styles       uiStyles
+	cpu          cpu.CPU
+	mem          mem.Memory
+	allProcesses []proc.Process // as collected, before filtering and expanding
+	processes    []processRow   // rows of the process list
+	groups       []proc.Group   // rows of grouped views
+	cgroups      []cgroup.Cgroup
+	listeners    []sock.Listener
+	filesystems  []disk.Filesystem
+	view         viewMode
+	filter       *processFilter
+	selectedRow  int
+	scrollOffset int
+	showIOTotals bool
+	settings     config.Config
+
+	commandScroll int            // characters of the commands scrolled past
+	columnWidths  map[string]int // widest value of each column over all rows
+
+	inspector inspector
+
+	groupByCommand   bool
+	expandedCommands map[string]bool
+
+	firingAlerts []*alert.Rule
+	alertFlash   bool
+
+	shownAt time.Time // when the samples on screen were received
+	pause   pauseState
+	follow  followState
+	notice  notice
+
+	messages chan message
+	done     <-chan struct{} // closed once the event loop stops
+
+	scanningPorts    bool // a scan of listening sockets is running
+	readingInspector bool // a tab of the inspector is being read
+	rereadInspector  bool // the tab changed during that read, read it again
 }
 
 type uiStyles struct {
 	text          tcell.Style
 	barBackground tcell.Style
 	selectedText  tcell.Style
+	exitedText    tcell.Style
+	hintText      tcell.Style
+	warningText   tcell.Style
 }
 
 func getBarStyle(usage float32) tcell.Style {
@@ -59,7 +136,7 @@ func getBarStyle(usage float32) tcell.Style {
 	}
 }
 
-func Init(cancelCtx context.CancelFunc) (UI, error) {
+func Init(cancelCtx context.CancelFunc, settings config.Config) (UI, error) {
 	s, err := tcell.NewScreen()
 	if err != nil {
 		return UI{}, err
@@ -69,28 +146,268 @@ func Init(cancelCtx context.CancelFunc) (UI, error) {
 		return UI{}, err
 	}
 
+	return newUI(s, settings), nil
+}
+
+// newUI sets up the UI state on a screen that is already initialised
+func newUI(s tcell.Screen, settings config.Config) UI {
 	ui := UI{
 		screen: s,
 		styles: uiStyles{
 			text:          tcell.StyleDefault.Foreground(tcell.NewRGBColor(248, 248, 242)),                                           // Soft white
 			barBackground: tcell.StyleDefault.Background(tcell.NewRGBColor(28, 33, 48)),                                              // Deep navy blue
 			selectedText:  tcell.StyleDefault.Background(tcell.NewRGBColor(68, 71, 90)).Foreground(tcell.NewRGBColor(248, 248, 242)), // Highlighted row
+			exitedText:    tcell.StyleDefault.Foreground(tcell.NewRGBColor(98, 114, 164)).Dim(true),                                  // Muted blue-grey
+			hintText:      tcell.StyleDefault.Foreground(tcell.NewRGBColor(98, 114, 164)),                                            // Blue-grey
+			warningText:   tcell.StyleDefault.Foreground(tcell.NewRGBColor(255, 85, 85)),                                             // Soft red
 		},
+		expandedCommands: make(map[string]bool),
+		settings:         settings,
+		messages:         make(chan message, MESSAGE_QUEUE_SIZE),
 	}
+	ui.settings.Columns = loadColumns(settings.Columns)
+	ui.settings.Layout = loadLayout(settings.Layout)
 
 	ui.setTerminalStyle()
-	return ui, nil
+	return ui
 }
 
 func (ui *UI) setTerminalStyle() {
 	ui.screen.SetStyle(tcell.StyleDefault)
 }
 
-func (ui *UI) update(cpu cpu.CPU, mem mem.Memory, processes []proc.Process) {
-	ui.cpu = cpu
-	ui.mem = mem
-	ui.processes = processes
-	ui.draw()
+// update checks alerts against the latest samples and shows them, or holds
+// them back while the display is paused
+func (ui *UI) update(snapshot collect.Snapshot) {
+	// Alerts keep watching while the display is paused
+	ui.evaluateAlerts(snapshot)
+
+	if ui.pause.paused {
+		ui.pause.pending = snapshot
+		ui.pause.skipped++
+		return
+	}
+
+	ui.show(snapshot)
+}
+
+// show puts samples on screen. What a disabled collector would have sampled
+// is left empty.
+func (ui *UI) show(snapshot collect.Snapshot) {
+	ui.cpu, _ = snapshot[cpu.NAME].(cpu.CPU)
+	ui.mem, _ = snapshot[mem.NAME].(mem.Memory)
+	ui.allProcesses, _ = snapshot[proc.NAME].([]proc.Process)
+	ui.cgroups, _ = snapshot[cgroup.NAME].([]cgroup.Cgroup)
+	ui.filesystems, _ = snapshot[disk.NAME].([]disk.Filesystem)
+	ui.shownAt = time.Now()
+
+	key, line := ui.selectedKey()
+	ui.refreshRows()
+	ui.reselect(key, line)
+	ui.followProcess()
+
+	switch ui.view {
+	case INSPECT_VIEW:
+		ui.refreshInspector()
+	case PORTS_VIEW:
+		ui.refreshPorts()
+	}
+}
+
+// refreshRows rebuilds the rows of the current view from the collected processes
+func (ui *UI) refreshRows() {
+	processes := ui.allProcesses
+
+	if ui.filter != nil {
+		processes = filterProcesses(processes, ui.filter.keep)
+	}
+
+	ui.processes = ui.buildProcessRows(processes)
+	ui.columnWidths = ui.measureColumns()
+	ui.groups = nil
+
+	switch ui.view {
+	case USER_VIEW:
+		ui.groups = proc.GroupBy(ui.allProcesses, func(p proc.Process) string {
+			return p.User
+		})
+	case SERVICE_VIEW:
+		ui.groups = groupByService(ui.allProcesses)
+	}
+
+	// Rows may have disappeared since the last refresh
+	ui.selectedRow = max(0, min(ui.selectedRow, ui.rowCount()-1))
+}
+
+func (ui *UI) rowCount() int {
+	switch ui.view {
+	case USER_VIEW, SERVICE_VIEW:
+		return len(ui.groups)
+	case CGROUP_VIEW:
+		return len(ui.cgroups)
+	case INSPECT_VIEW:
+		return len(ui.inspector.lines)
+	case PORTS_VIEW:
+		return len(ui.listeners)
+	case FILESYSTEM_VIEW:
+		return len(ui.filesystems)
+	case COLUMNS_VIEW:
+		return len(processColumns)
+	default:
+		return len(ui.processes)
+	}
+}
+
+// toggleView switches between the process list and another view
+func (ui *UI) toggleView(view viewMode) {
+	ui.filter = nil
+
+	if ui.view == view {
+		ui.setView(PROCESS_VIEW)
+	} else {
+		ui.setView(view)
+	}
+}
+
+// setView switches to another view with the selection back at the top
+func (ui *UI) setView(view viewMode) {
+	ui.view = view
+	ui.selectedRow = 0
+	ui.scrollOffset = 0
+	ui.refreshRows()
+}
+
+// openSelected drills down into the selected row of a grouped view, expands
+// the selected group of processes or inspects the selected process
+func (ui *UI) openSelected() {
+	switch {
+	case ui.view == USER_VIEW && ui.selectedRow < len(ui.groups):
+		user := ui.groups[ui.selectedRow].Name
+		ui.filter = &processFilter{
+			label: "user " + user,
+			from:  USER_VIEW,
+			keep: func(p proc.Process) bool {
+				return p.User == user
+			},
+		}
+		ui.setView(PROCESS_VIEW)
+	case ui.view == SERVICE_VIEW && ui.selectedRow < len(ui.groups):
+		unitPath := ui.groups[ui.selectedRow].Name
+		ui.filter = &processFilter{
+			label: "unit " + serviceLabel(ui.groups[ui.selectedRow]),
+			from:  SERVICE_VIEW,
+			keep: func(p proc.Process) bool {
+				_, processUnitPath := cgroup.Unit(p.Cgroup)
+				return processUnitPath == unitPath
+			},
+		}
+		ui.setView(PROCESS_VIEW)
+	case ui.view == CGROUP_VIEW && ui.selectedRow < len(ui.cgroups):
+		cgroupPath := ui.cgroups[ui.selectedRow].Path
+		ui.filter = &processFilter{
+			label: "cgroup " + cgroupPath,
+			from:  CGROUP_VIEW,
+			keep: func(p proc.Process) bool {
+				return inCgroup(p.Cgroup, cgroupPath)
+			},
+		}
+		ui.setView(PROCESS_VIEW)
+	case ui.view == PORTS_VIEW:
+		ui.openSelectedPort()
+	case ui.view == COLUMNS_VIEW:
+		ui.toggleSelectedColumn()
+	case ui.view == PROCESS_VIEW && ui.selectedRow < len(ui.processes) && ui.processes[ui.selectedRow].groupSize > 0:
+		ui.toggleGroup()
+	case ui.view == PROCESS_VIEW:
+		ui.openInspector()
+	}
+}
+
+// goBack leaves the current drill-down or view. It reports false when
+// there is nothing left to go back from.
+func (ui *UI) goBack() bool {
+	switch {
+	case ui.view == INSPECT_VIEW:
+		ui.closeInspector()
+	case ui.filter != nil:
+		from := ui.filter.from
+		ui.filter = nil
+		ui.setView(from)
+	case ui.view != PROCESS_VIEW:
+		ui.setView(PROCESS_VIEW)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// cycleNamespaceFilter narrows the process list down to processes sharing the
+// PID, then the network, then the mount namespace of the selected process,
+// and finally lifts the filter
+func (ui *UI) cycleNamespaceFilter() {
+	var namespaces proc.Namespaces
+	next := 0
+
+	switch {
+	case ui.filter != nil && ui.filter.namespaceKind != "":
+		namespaces = ui.filter.namespaces
+		next = slices.Index(namespaceKinds, ui.filter.namespaceKind) + 1
+	case ui.view == PROCESS_VIEW && ui.selectedRow < len(ui.processes) && ui.processes[ui.selectedRow].groupSize == 0:
+		namespaces = ui.processes[ui.selectedRow].Namespaces
+	default:
+		return
+	}
+
+	// Namespaces of processes we may not inspect can't be compared, so skip them
+	for next < len(namespaceKinds) && namespaceInode(namespaces, namespaceKinds[next]) == "" {
+		next++
+	}
+
+	if next >= len(namespaceKinds) {
+		ui.filter = nil
+		ui.setView(PROCESS_VIEW)
+		return
+	}
+
+	kind := namespaceKinds[next]
+	inode := namespaceInode(namespaces, kind)
+
+	ui.filter = &processFilter{
+		label: fmt.Sprintf("%sns %s, n: next", kind, inode),
+		from:  PROCESS_VIEW,
+		keep: func(p proc.Process) bool {
+			return namespaceInode(p.Namespaces, kind) == inode
+		},
+		namespaceKind: kind,
+		namespaces:    namespaces,
+	}
+	ui.setView(PROCESS_VIEW)
+}
+
+func namespaceInode(namespaces proc.Namespaces, kind string) string {
+	switch kind {
+	case "pid":
+		return namespaces.PID
+	case "net":
+		return namespaces.Net
+	case "mnt":
+		return namespaces.Mnt
+	}
+
+	return ""
+}
+
+func filterProcesses(processes []proc.Process, keep func(proc.Process) bool) []proc.Process {
+	var filtered []proc.Process
+
+	for _, p := range processes {
+		if keep(p) {
+			filtered = append(filtered, p)
+		}
+	}
+
+	return filtered
 }
 
 func (ui *UI) draw() {
@@ -98,22 +415,75 @@ func (ui *UI) draw() {
 	width, height := ui.screen.Size()
 
 	dimensions := ui.calculateDimensions(width)
+	layout := ui.computeLayout(width, height)
+
+	// Add refresh rate display at the top right, or the summary of small terminals
+	// The status keeps to the right half of the line, clear of the panel titles
+	statusStart, statusEnd := width/2, width
+	if layout.minimal {
+		statusStart = ui.renderSummary(dimensions.startWidth, 0) + 2
+	} else {
+		statusEnd = ui.renderRefreshRate(dimensions)
+	}
+	ui.renderStatus(statusStart, statusEnd, 0)
 
-	// Add refresh rate display at the top right
-	ui.renderRefreshRate(dimensions)
-
-	lastPos := ui.renderCPUCores(dimensions)
-	lastPos = ui.renderMemorySection(dimensions, lastPos)
-
-	// Add a gap before process list
-	lastPos += 1
+	if layout.cpuRows > 0 {
+		ui.renderCPUCores(dimensions, layout)
+	}
+	if layout.memoryY >= 0 {
+		ui.renderMemorySection(dimensions, layout.memoryY+1)
+	}
 
-	ui.renderProcessList(dimensions, lastPos, height-lastPos)
+	lastPos := layout.listY
+	listHeight := layout.listHeight
+
+	// Keep the last line for details of the selected row
+	switch ui.view {
+	case USER_VIEW:
+		ui.renderGroupList(dimensions, lastPos, listHeight, groupListColumns{nameHeader: "USER"})
+		ui.renderHint(dimensions, height-1, "Enter: show processes of the selected user  Esc: back")
+	case CGROUP_VIEW:
+		ui.renderCgroupList(dimensions, lastPos, listHeight)
+		ui.renderHint(dimensions, height-1, "Enter: show processes in the selected cgroup  Esc: back")
+	case SERVICE_VIEW:
+		ui.renderGroupList(dimensions, lastPos, listHeight, groupListColumns{
+			nameHeader: "UNIT",
+			label:      serviceLabel,
+			limit:      ui.serviceMemoryLimit,
+		})
+		ui.renderHint(dimensions, height-1, "Enter: show processes of the selected unit  Esc: back")
+	case INSPECT_VIEW:
+		ui.renderInspector(dimensions, lastPos, listHeight)
+		hint := "Tab: next tab  Esc: back"
+		switch {
+		case ui.inspector.editingFilter:
+			hint = "Filter: " + ui.inspector.environFilter + "_  Enter: keep  Esc: clear"
+		case ui.inspector.tab == MEMORY_TAB:
+			hint = "Tab: next tab  o: sort by next column  Esc: back"
+		case ui.inspector.tab == ENVIRON_TAB:
+			hint = "Tab: next tab  /: filter  Esc: back"
+		}
+		ui.renderHint(dimensions, height-1, hint)
+	case PORTS_VIEW:
+		ui.renderPortList(dimensions, lastPos, listHeight)
+		ui.renderHint(dimensions, height-1, "Enter: jump to the process holding the selected socket  Esc: back")
+	case FILESYSTEM_VIEW:
+		ui.renderFilesystemList(dimensions, lastPos, listHeight)
+		ui.renderFilesystemDetails(dimensions, height-1)
+	case COLUMNS_VIEW:
+		ui.renderColumnChooser(dimensions, lastPos, listHeight)
+		ui.renderHint(dimensions, height-1, "Space: show or hide  [ ]: move left or right  Esc: back")
+	default:
+		ui.renderProcessList(dimensions, lastPos, listHeight)
+		ui.renderProcessDetails(dimensions, height-1)
+	}
 
 	ui.screen.Show()
 }
 
-func (ui *UI) renderRefreshRate(dim displayDimensions) {
+// renderRefreshRate draws the refresh rate at the top right and returns the
+// column it starts at
+func (ui *UI) renderRefreshRate(dim displayDimensions) int {
 	refreshRate := shared.GetRefreshRate()
 
 	// Position at top right
@@ -132,12 +502,51 @@ func (ui *UI) renderRefreshRate(dim displayDimensions) {
 	emitStr(ui.screen, decreaseX, 0, arrowStyle, "<")
 	emitStr(ui.screen, x+1, 0, defaultStyle, fmt.Sprintf(" %dms ", refreshRate))
 	emitStr(ui.screen, increaseX, 0, arrowStyle, ">")
+
+	return x
 }
 
 type displayDimensions struct {
 	barLen, boxWidth, totalWidth, startWidth, maxUsageLen int
 }
 
+// renderStatus draws notices, the pause and follow indicators and firing
+// alerts on line y, right to left from column end, cutting off what would
+// reach left of column start
+func (ui *UI) renderStatus(start, end, y int) {
+	x := end - 1
+
+	var parts []string
+	var styles []tcell.Style
+
+	if ui.pause.paused {
+		parts = append(parts, ui.pauseIndicator())
+		styles = append(styles, ui.styles.warningText.Reverse(true))
+	}
+	if ui.follow.pid != "" {
+		parts = append(parts, "FOLLOWING "+ui.follow.pid)
+		styles = append(styles, ui.styles.hintText.Reverse(true))
+	}
+	if time.Now().Before(ui.notice.until) {
+		parts = append(parts, ui.notice.text)
+		styles = append(styles, ui.styles.warningText)
+	}
+
+	for i, text := range parts {
+		room := x - start
+		if room <= 0 {
+			return
+		}
+
+		text = truncateString(text, min(len([]rune(text)), room))
+		x -= len([]rune(text))
+		emitStr(ui.screen, x, y, styles[i], text)
+		x--
+	}
+
+	ui.renderAlerts(start, x, y)
+}
+
 func (ui *UI) calculateDimensions(screenWidth int) displayDimensions {
 	maxUsageLen := len(fmt.Sprintf("(%.2f%%)", 100.00))
 
@@ -159,65 +568,63 @@ func (ui *UI) calculateDimensions(screenWidth int) displayDimensions {
 	}
 }
 
-func (ui *UI) renderCPUCores(dim displayDimensions) int {
-	startHeight := 1
-	coreStartHeight := startHeight
-	coreStartWidth := dim.startWidth
-	coreCount := 0
+// renderCPUCores draws a meter per core, so many to a row, or the heatmap
+func (ui *UI) renderCPUCores(dim displayDimensions, layout screenLayout) {
+	if layout.coresPerRow == 0 {
+		ui.renderCPUHeatmap(dim, layout)
+		return
+	}
+
+	boxWidth := meterWidth(dim.totalWidth, layout.coresPerRow)
 
-	for idx, core := range ui.cpu.Cores {
-		if coreCount == 1 {
-			coreStartWidth = dim.startWidth + dim.boxWidth + GAP_BETWEEN_BOXES
-		}
+	// The last meter gives way to a count of the cores that don't fit
+	shown := len(ui.cpu.Cores)
+	if hidden := ui.hiddenCores(layout.coresPerRow, layout.cpuRows); hidden > 0 {
+		shown = layout.coresPerRow*layout.cpuRows - 1
+		x := dim.startWidth + (shown%layout.coresPerRow)*(boxWidth+GAP_BETWEEN_BOXES)
+		emitStr(ui.screen, x, layout.cpuY+(shown/layout.coresPerRow)*2, ui.styles.hintText, fmt.Sprintf("+%d cores", hidden+1))
+	}
+
+	for idx, core := range ui.cpu.Cores[:shown] {
+		row, col := idx/layout.coresPerRow, idx%layout.coresPerRow
 
 		ui.renderCPUBox(
-			coreStartWidth,
-			coreStartHeight,
-			dim.boxWidth,
+			dim.startWidth+col*(boxWidth+GAP_BETWEEN_BOXES),
+			layout.cpuY+row*2+1,
+			boxWidth,
 			idx,
 			core.Usage,
-			dim.barLen,
+			boxWidth,
 			dim.maxUsageLen,
 		)
-
-		coreCount++
-		if coreCount >= CORES_PER_ROW {
-			coreStartWidth = dim.startWidth
-			coreStartHeight += 2
-			coreCount = 0
-		}
 	}
-
-	return coreStartHeight + 1
 }
 
 func (ui *UI) renderCPUBox(x, y, boxWidth, coreIdx int, usage float32, barLen, maxUsageLen int) {
 	currentX := x
 
 	coreTitle := fmt.Sprintf("CPU%d (%.1f%%)", coreIdx, usage)
-	emitStr(ui.screen, currentX, y-1, ui.styles.text, coreTitle)
+	emitStr(ui.screen, currentX, y-1, ui.panelStyle(alert.PANEL_CPU), coreTitle)
 
 	ui.renderColoredBar(currentX, y, usage, barLen)
 }
 
-func (ui *UI) renderMemorySection(dim displayDimensions, startHeight int) int {
-	memoryTitle := fmt.Sprintf("MEM (%.1f/%.1fG)", float32(ui.mem.Allocated)/float32(1000000), float32(ui.mem.Total)/float32(1000000))
+func (ui *UI) renderMemorySection(dim displayDimensions, startHeight int) {
+	memoryTitle := fmt.Sprintf("MEM (%s/%s)", format.KiB(int64(ui.mem.Allocated)), format.KiB(int64(ui.mem.Total)))
 	currentX := dim.startWidth
 
 	// Draw memory title and bar
-	emitStr(ui.screen, currentX, startHeight-1, ui.styles.text, memoryTitle)
+	emitStr(ui.screen, currentX, startHeight-1, ui.panelStyle(alert.PANEL_MEMORY), memoryTitle)
 	ui.renderColoredBar(currentX, startHeight, ui.mem.Usage, dim.barLen)
 
 	if ui.mem.Swap != nil {
 		swapX := dim.startWidth + dim.boxWidth + GAP_BETWEEN_BOXES
-		swapTitle := fmt.Sprintf("SWP (%.1f/%.1fG)", float32(ui.mem.Swap.Allocated)/float32(1000000), float32(ui.mem.Swap.Total)/float32(1000000))
+		swapTitle := fmt.Sprintf("SWP (%s/%s)", format.KiB(int64(ui.mem.Swap.Allocated)), format.KiB(int64(ui.mem.Swap.Total)))
 
 		// Draw swap title and bar
-		emitStr(ui.screen, swapX, startHeight-1, ui.styles.text, swapTitle)
+		emitStr(ui.screen, swapX, startHeight-1, ui.panelStyle(alert.PANEL_MEMORY), swapTitle)
 		ui.renderColoredBar(swapX, startHeight, ui.mem.Swap.Usage, dim.barLen)
 	}
-
-	return startHeight + 1
 }
 
 func (ui *UI) renderProcessList(dim displayDimensions, startY, maxHeight int) {
@@ -225,16 +632,6 @@ func (ui *UI) renderProcessList(dim displayDimensions, startY, maxHeight int) {
 		return
 	}
 
-	// Calculate column widths based on available space
-	otherColumnsWidth := 8 + 8 + 8 + 6 + 6 + 8             // PID + STATE + PRIO + CPU% + MEM% + IO
-	commandWidth := dim.totalWidth - otherColumnsWidth - 6 // -6 for spacing between columns
-
-	// Header
-	header := fmt.Sprintf("%-8s %-*s %-8s %-8s %6s %6s %8s",
-		"PID", commandWidth, "COMMAND", "STATE", "PRIO", "CPU%", "MEM%", "IO")
-	emitStr(ui.screen, dim.startWidth, startY, ui.styles.text, header)
-	startY++
-
 	// Calculate visible range
 	visibleCount := maxHeight - 1 // -1 for header
 	if visibleCount < 0 {
@@ -247,34 +644,31 @@ func (ui *UI) renderProcessList(dim displayDimensions, startY, maxHeight int) {
 		endIdx = len(ui.processes)
 	}
 
+	columns := ui.layoutColumns(ui.scrollOffset, endIdx, dim.totalWidth)
+
+	// Header
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = fitColumn(c.header, c.width, c.left)
+	}
+	emitStr(ui.screen, dim.startWidth, startY, ui.panelStyle(alert.PANEL_PROCESSES), strings.Join(headers, " "))
+	startY++
+
 	// Render visible processes
+	cells := make([]string, len(columns))
 	for i := ui.scrollOffset; i < endIdx; i++ {
 		proc := ui.processes[i]
 
-		// Format CPU, MEM, and IO values
-		cpuStr := fmt.Sprintf("%5.1f%%", proc.CpuUsage)
-		memStr := fmt.Sprintf("%5.1f%%", proc.MemUsage)
-
-		// Safely format IO value
-		var ioStr string
-		if proc.IO >= 0 {
-			ioStr = fmt.Sprintf("%7.1f", float32(proc.IO)/float32(1024*1024))
-		} else {
-			ioStr = "   N/A"
+		for j, c := range columns {
+			cells[j] = fitColumn(c.cells[i-ui.scrollOffset], c.width, c.left)
 		}
-
-		processLine := fmt.Sprintf("%-8s %-*s %-8s %-8s %6s %6s %8s",
-			proc.ID,
-			commandWidth, truncateString(proc.Command, commandWidth),
-			proc.State,
-			proc.Priority,
-			cpuStr,
-			memStr,
-			ioStr,
-		)
+		processLine := strings.Join(cells, " ")
 
 		style := ui.styles.text
-		if i == ui.selectedProcess {
+		if proc.Exited {
+			style = ui.styles.exitedText
+		}
+		if i == ui.selectedRow {
 			style = ui.styles.selectedText
 		}
 
@@ -282,15 +676,72 @@ func (ui *UI) renderProcessList(dim displayDimensions, startY, maxHeight int) {
 	}
 }
 
+// renderProcessDetails shows the counters of the selected process that don't fit in a column
+func (ui *UI) renderProcessDetails(dim displayDimensions, y int) {
+	if ui.selectedRow < 0 || ui.selectedRow >= len(ui.processes) {
+		return
+	}
+
+	proc := ui.processes[ui.selectedRow]
+
+	subject := "PID " + proc.ID
+	if proc.groupSize > 0 {
+		subject = fmt.Sprintf("%d × %s (Enter: expand)", proc.groupSize, proc.Command)
+	}
+
+	details := fmt.Sprintf("%s  IO not readable", subject)
+	if proc.IO.Readable {
+		details = fmt.Sprintf("%s  read %s  written %s  cancelled %s  syscalls %d read / %d write",
+			subject,
+			format.Bytes(proc.IO.ReadTotal),
+			format.Bytes(proc.IO.WriteTotal),
+			format.Bytes(proc.IO.CancelledWriteTotal),
+			proc.IO.ReadSyscalls,
+			proc.IO.WriteSyscalls,
+		)
+	}
+
+	if ui.filter != nil {
+		details = fmt.Sprintf("[%s, Esc: back]  %s", ui.filter.label, details)
+	}
+
+	emitStr(ui.screen, dim.startWidth, y, ui.styles.text, details)
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+// Helper function to right-align values into fixed-width columns
+func rightAligned(values []string, width int) string {
+	var b strings.Builder
+
+	for _, v := range values {
+		fmt.Fprintf(&b, " %*s", width, v)
+	}
+
+	return b.String()
+}
+
 // Helper function to truncate strings that are too long
 func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	// Counted in runes, as the padding is, so a cut never splits a character
+	runes := []rune(s)
+
+	if len(runes) <= maxLen {
 		// If string is shorter than maxLen, right-pad with spaces
 		return fmt.Sprintf("%-*s", maxLen, s)
 	}
 
 	// If longer than maxLen, truncate and add ellipsis
-	return fmt.Sprintf("%-*s", maxLen, s[:maxLen-3]+"...")
+	if maxLen < 3 {
+		return string(runes[:max(0, maxLen)])
+	}
+	return fmt.Sprintf("%-*s", maxLen, string(runes[:maxLen-3])+"...")
 }
 
 func emitStr(s tcell.Screen, x, y int, style tcell.Style, str string) {
@@ -307,82 +758,167 @@ func emitStr(s tcell.Screen, x, y int, style tcell.Style, str string) {
 	}
 }
 
-func (ui *UI) pollAndListenToEvents(cancelCtx context.CancelFunc) {
-	for {
-		ev := ui.screen.PollEvent()
-
-		switch ev := ev.(type) {
-		case *tcell.EventResize:
-			ui.screen.Sync()
-			ui.draw()
-		case *tcell.EventKey:
-			switch ev.Key() {
-			case tcell.KeyEscape, tcell.KeyCtrlC:
-				cancelCtx()
-				ui.screen.Fini()
-				os.Exit(0)
-			case tcell.KeyUp:
-				ui.moveSelection(-1)
-				ui.draw()
-			case tcell.KeyDown:
-				ui.moveSelection(1)
-				ui.draw()
+// handleEvent applies a key press or resize to the UI state; the event loop
+// redraws afterwards. It reports true when the user quits.
+func (ui *UI) handleEvent(ev tcell.Event) (quit bool) {
+	switch ev := ev.(type) {
+	case nil:
+		// The screen was finalized
+		return true
+	case *tcell.EventResize:
+		ui.screen.Sync()
+	case *tcell.EventKey:
+		if ui.view == INSPECT_VIEW && ui.inspector.editingFilter {
+			ui.editEnvironFilter(ev)
+			break
+		}
+
+		switch ev.Key() {
+		case tcell.KeyEscape:
+			return !ui.goBack()
+		case tcell.KeyCtrlC:
+			return true
+		case tcell.KeyEnter:
+			ui.openSelected()
+		case tcell.KeyTab:
+			if ui.view == INSPECT_VIEW {
+				ui.nextInspectorTab()
 			}
-			switch ev.Rune() {
-			case ',', '<':
-				shared.DecreaseRefreshRate(100)
-				ui.draw()
-			case '.', '>':
-				shared.IncreaseRefreshRate(100)
-				ui.draw()
+		case tcell.KeyUp:
+			ui.moveSelection(-1)
+		case tcell.KeyDown:
+			ui.moveSelection(1)
+		case tcell.KeyLeft:
+			if ui.view == PROCESS_VIEW {
+				ui.scrollCommand(-COMMAND_SCROLL_STEP)
+			}
+		case tcell.KeyRight:
+			if ui.view == PROCESS_VIEW {
+				ui.scrollCommand(COMMAND_SCROLL_STEP)
+			}
+		}
+		switch ev.Rune() {
+		case ',', '<':
+			shared.DecreaseRefreshRate(100)
+		case '.', '>':
+			shared.IncreaseRefreshRate(100)
+		case 'u':
+			format.ToggleUnits()
+		case 'S':
+			proc.SetSmapsRollup(!proc.SmapsRollupEnabled())
+		case 'i':
+			ui.showIOTotals = !ui.showIOTotals
+		case 'H':
+			proc.SetThreads(!proc.ThreadsEnabled())
+		case 'a':
+			proc.SetCommandLines(!proc.CommandLinesEnabled())
+			ui.commandScroll = 0
+		case 'g':
+			ui.groupByCommand = !ui.groupByCommand
+			ui.selectedRow = 0
+			ui.scrollOffset = 0
+			ui.refreshRows()
+		case 'U':
+			ui.toggleView(USER_VIEW)
+		case 'c':
+			ui.toggleView(CGROUP_VIEW)
+		case 's':
+			ui.toggleView(SERVICE_VIEW)
+		case 'N':
+			ui.toggleNamespaceColumns()
+		case 'n':
+			ui.cycleNamespaceFilter()
+		case '/':
+			if ui.view == INSPECT_VIEW && ui.inspector.tab == ENVIRON_TAB {
+				ui.inspector.editingFilter = true
+			}
+		case 'o':
+			if ui.view == INSPECT_VIEW {
+				ui.cycleMappingSort()
+			}
+		case 'd':
+			ui.toggleView(FILESYSTEM_VIEW)
+		case 'A':
+			disk.SetShowAll(!disk.ShowAllEnabled())
+		case 'p':
+			ui.togglePause()
+		case 'F':
+			ui.toggleFollow()
+		case 'C':
+			ui.toggleView(COLUMNS_VIEW)
+		case '1':
+			ui.toggleCPUPanel()
+		case '2':
+			ui.toggleMemoryPanel()
+		case 'm':
+			ui.cycleCPUMeters()
+		case '+', '=':
+			ui.resizePanels(METER_SHARE_STEP)
+		case '-':
+			ui.resizePanels(-METER_SHARE_STEP)
+		case ' ':
+			if ui.view == COLUMNS_VIEW {
+				ui.toggleSelectedColumn()
+			}
+		case '[':
+			if ui.view == COLUMNS_VIEW {
+				ui.moveSelectedColumn(-1)
+			}
+		case ']':
+			if ui.view == COLUMNS_VIEW {
+				ui.moveSelectedColumn(1)
+			}
+		case 'l':
+			ui.toggleView(PORTS_VIEW)
+			if ui.view == PORTS_VIEW {
+				ui.refreshPorts()
 			}
 		}
 	}
+
+	return false
 }
 
 func (ui *UI) moveSelection(delta int) {
-	if len(ui.processes) == 0 {
+	rowCount := ui.rowCount()
+	if rowCount == 0 {
 		return
 	}
 
-	// Calculate visible area
-	_, height := ui.screen.Size()
-	// CPU section height: number of CPU core pairs * 2 (each pair takes 2 rows)
-	cpuHeight := ((len(ui.cpu.Cores) + 1) / 2) * 2
-	// Total header height: CPU section + 1 for memory + 1 for gap + 1 for process header
-	headerHeight := cpuHeight + 3
-	visibleHeight := height - headerHeight
-	if visibleHeight < 0 {
-		visibleHeight = 0
+	// Moving the selection by hand lets go of the followed process
+	if ui.view == PROCESS_VIEW {
+		ui.follow = followState{}
 	}
 
+	visibleHeight := ui.visibleRows()
+
 	// Calculate new selection
-	newSelection := ui.selectedProcess + delta
+	newSelection := ui.selectedRow + delta
 
 	// Bounds checking for selection
 	if newSelection < 0 {
 		newSelection = 0
-	} else if newSelection >= len(ui.processes) {
-		newSelection = len(ui.processes) - 1
+	} else if newSelection >= rowCount {
+		newSelection = rowCount - 1
 	}
 
 	// Update selection
-	ui.selectedProcess = newSelection
+	ui.selectedRow = newSelection
 
 	// Calculate scroll boundaries
-	maxScroll := max(0, len(ui.processes)-visibleHeight)
+	maxScroll := max(0, rowCount-visibleHeight)
 
 	// Start scrolling when 3 processes remain from bottom
 	const scrollBuffer = 3
 
 	// If moving down and selection is getting close to bottom of visible area
-	if delta > 0 && ui.selectedProcess >= ui.scrollOffset+visibleHeight-scrollBuffer {
-		ui.scrollOffset = min(ui.selectedProcess-visibleHeight+scrollBuffer, maxScroll)
+	if delta > 0 && ui.selectedRow >= ui.scrollOffset+visibleHeight-scrollBuffer {
+		ui.scrollOffset = min(ui.selectedRow-visibleHeight+scrollBuffer, maxScroll)
 	}
 
 	// If moving up and selection is at the top of visible area
-	if delta < 0 && ui.selectedProcess <= ui.scrollOffset {
-		ui.scrollOffset = ui.selectedProcess
+	if delta < 0 && ui.selectedRow <= ui.scrollOffset {
+		ui.scrollOffset = ui.selectedRow
 	}
 
 	// Ensure scroll offset stays within bounds
@@ -394,6 +930,18 @@ func (ui *UI) moveSelection(delta int) {
 	}
 }
 
+// visibleRows is how many rows of the current view fit on the screen
+func (ui *UI) visibleRows() int {
+	width, height := ui.screen.Size()
+	rows := ui.computeLayout(width, height).listHeight - 1 // -1 for header
+	if ui.view == INSPECT_VIEW {
+		// The inspector has a tab bar above its header
+		rows--
+	}
+
+	return max(0, rows)
+}
+
 func (ui *UI) renderColoredBar(x, y int, usage float32, barLen int) {
 	filled := min(int((usage/100)*float32(barLen)), barLen)
 
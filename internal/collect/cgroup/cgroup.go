@@ -0,0 +1,267 @@
+package cgroup
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amirdaraby/titop/internal/collect"
+	"github.com/amirdaraby/titop/internal/reader"
+	"github.com/amirdaraby/titop/internal/shared"
+)
+
+var cgroupLastStates map[string]cgroupStat = make(map[string]cgroupStat)
+
+// What a cgroup path was recognized as
+const (
+	KIND_DOCKER     = "docker"
+	KIND_CONTAINERD = "containerd"
+	KIND_PODMAN     = "podman"
+	KIND_KUBEPODS   = "kubepods"
+	KIND_LXC        = "lxc"
+	KIND_SERVICE    = "service"
+	KIND_SCOPE      = "scope"
+	KIND_SLICE      = "slice"
+)
+
+// Short container IDs, as docker ps shows them
+const CONTAINER_ID_LENGTH = 12
+
+var containerPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{KIND_DOCKER, regexp.MustCompile(`^docker-([0-9a-f]{12,})\.scope$`)},
+	{KIND_CONTAINERD, regexp.MustCompile(`^cri-containerd-([0-9a-f]{12,})\.scope$`)},
+	{KIND_PODMAN, regexp.MustCompile(`^libpod-([0-9a-f]{12,})\.scope$`)},
+	{KIND_LXC, regexp.MustCompile(`^lxc\.payload\.(.+)$`)},
+}
+
+// Parent directories of containers started with the cgroupfs driver
+var containerParents = map[string]string{
+	"docker":     KIND_DOCKER,
+	"containerd": KIND_CONTAINERD,
+	"libpod":     KIND_PODMAN,
+}
+
+type Cgroup struct {
+	Path          string // relative to the cgroup2 root
+	Kind          string // one of the KIND_ constants, empty when not recognized
+	Name          string // container ID or systemd unit; the last path element otherwise
+	Depth         int
+	CpuUsage      float32 // percent of all cores
+	MemoryCurrent int64   // bytes
+	MemoryMax     int64   // bytes, 0 when unlimited
+	ReadRate      int64   // bytes per second
+	WriteRate     int64   // bytes per second
+	PidsCurrent   int64
+
+	// Whether the memory and pids controllers are enabled for the cgroup;
+	// the root cgroup never has their files
+	HasMemory, HasPids bool
+}
+
+type cgroupStat struct {
+	usageUsec, readBytes, writeBytes int64
+	sampledAt                        time.Time
+}
+
+func Usage(ctx context.Context) ([]Cgroup, error) {
+	cgroupsContent := reader.ReadCgroups("cgroup.events", "cpu.stat", "memory.current", "memory.max", "io.stat", "pids.current")
+
+	var cgroups []Cgroup
+	currentStates := make(map[string]cgroupStat)
+	numCores := float64(shared.GetConfig().CoresCount)
+
+	for _, content := range cgroupsContent {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		files := content.Files
+
+		// Cgroups without processes in their subtree are only noise
+		if events, exists := files["cgroup.events"]; exists && strings.Contains(string(events), "populated 0") {
+			continue
+		}
+
+		kind, name := Classify(content.Path)
+
+		cgroup := Cgroup{
+			Path:          content.Path,
+			Kind:          kind,
+			Name:          name,
+			Depth:         strings.Count(strings.TrimSuffix(content.Path, "/"), "/"),
+			MemoryCurrent: parseSingleValue(files["memory.current"]),
+			MemoryMax:     parseSingleValue(files["memory.max"]),
+			PidsCurrent:   parseSingleValue(files["pids.current"]),
+		}
+
+		_, cgroup.HasMemory = files["memory.current"]
+		_, cgroup.HasPids = files["pids.current"]
+
+		readBytes, writeBytes := parseIOStat(files["io.stat"])
+
+		currentStat := cgroupStat{
+			usageUsec:  parseFlatKeyed(files["cpu.stat"])["usage_usec"],
+			readBytes:  readBytes,
+			writeBytes: writeBytes,
+			sampledAt:  content.ReadAt,
+		}
+
+		lastStat, exists := cgroupLastStates[content.Path]
+		if !exists {
+			lastStat = currentStat
+		}
+
+		elapsed := currentStat.sampledAt.Sub(lastStat.sampledAt).Seconds()
+
+		if elapsed > 0 {
+			usedSeconds := float64(currentStat.usageUsec-lastStat.usageUsec) / 1e6
+			cgroup.CpuUsage = float32(max(0, usedSeconds/elapsed/numCores*100))
+			cgroup.ReadRate = int64(float64(max(0, currentStat.readBytes-lastStat.readBytes)) / elapsed)
+			cgroup.WriteRate = int64(float64(max(0, currentStat.writeBytes-lastStat.writeBytes)) / elapsed)
+		}
+
+		currentStates[content.Path] = currentStat
+		cgroups = append(cgroups, cgroup)
+	}
+
+	// Replacing the map evicts removed cgroups
+	cgroupLastStates = currentStates
+
+	return cgroups, nil
+}
+
+// Classify recognizes containers and systemd units from a cgroup path, e.g.
+// "/system.slice/docker-<id>.scope" is a docker container and
+// "/system.slice/nginx.service" is the nginx.service unit.
+func Classify(cgroupPath string) (kind, name string) {
+	base := path.Base(cgroupPath)
+	parent := path.Base(path.Dir(cgroupPath))
+
+	for _, c := range containerPatterns {
+		if match := c.pattern.FindStringSubmatch(base); match != nil {
+			return c.kind, shortID(match[1])
+		}
+	}
+
+	if kind, exists := containerParents[parent]; exists {
+		return kind, shortID(base)
+	}
+
+	switch {
+	case strings.HasPrefix(base, "kubepods"):
+		return KIND_KUBEPODS, base
+	case strings.HasSuffix(base, ".service"):
+		return KIND_SERVICE, base
+	case strings.HasSuffix(base, ".scope"):
+		return KIND_SCOPE, base
+	case strings.HasSuffix(base, ".slice"):
+		return KIND_SLICE, base
+	}
+
+	return "", base
+}
+
+// Unit returns the systemd unit a cgroup belongs to, and the cgroup path of
+// that unit. The deepest unit wins, so a service of a user manager is
+// reported rather than user@<uid>.service. Both are empty outside any unit.
+func Unit(cgroupPath string) (unit, unitPath string) {
+	elements := strings.Split(cgroupPath, "/")
+
+	for i := len(elements) - 1; i >= 0; i-- {
+		if strings.HasSuffix(elements[i], ".service") || strings.HasSuffix(elements[i], ".scope") {
+			return elements[i], strings.Join(elements[:i+1], "/")
+		}
+	}
+
+	return "", ""
+}
+
+func shortID(id string) string {
+	if len(id) > CONTAINER_ID_LENGTH {
+		return id[:CONTAINER_ID_LENGTH]
+	}
+
+	return id
+}
+
+// parseSingleValue parses files holding one number, where "max" means no limit
+func parseSingleValue(content []byte) int64 {
+	value, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+
+	if err != nil {
+		return 0
+	}
+
+	return value
+}
+
+// parseFlatKeyed parses "key value" lines, the format of cpu.stat
+func parseFlatKeyed(content []byte) map[string]int64 {
+	values := make(map[string]int64)
+
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) != 2 {
+			continue
+		}
+
+		value, err := strconv.ParseInt(fields[1], 10, 64)
+		if err == nil {
+			values[fields[0]] = value
+		}
+	}
+
+	return values
+}
+
+// parseIOStat sums rbytes and wbytes over every device in io.stat, where
+// each line looks like "8:0 rbytes=1 wbytes=2 rios=3 wios=4 dbytes=0 dios=0"
+func parseIOStat(content []byte) (readBytes, writeBytes int64) {
+	for _, line := range strings.Split(string(content), "\n") {
+		for _, field := range strings.Fields(line) {
+			key, valStr, found := strings.Cut(field, "=")
+
+			if !found {
+				continue
+			}
+
+			value, err := strconv.ParseInt(valStr, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			switch key {
+			case "rbytes":
+				readBytes += value
+			case "wbytes":
+				writeBytes += value
+			}
+		}
+	}
+
+	return readBytes, writeBytes
+}
+
+const NAME = "cgroups"
+
+// Collector samples every populated cgroup
+type Collector struct{}
+
+func (Collector) Name() string {
+	return NAME
+}
+
+func (Collector) Interval() time.Duration {
+	return 0
+}
+
+func (Collector) Collect(ctx context.Context) (collect.Sample, error) {
+	return Usage(ctx)
+}
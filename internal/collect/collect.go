@@ -1,13 +1,219 @@
 package collect
 
 import (
-	"github.com/amirdaraby/titop/internal/collect/cpu"
-	"github.com/amirdaraby/titop/internal/collect/mem"
-	"github.com/amirdaraby/titop/internal/collect/proc"
+	"context"
+	"fmt"
+	"sync"
+	"time"
 )
 
-func Collect(cpuRes chan cpu.CPU, memRes chan mem.Memory, processesRes chan []proc.Process) {
-	go cpu.SendUsage(cpuRes)
-	go mem.SendUsage(memRes)
-	go proc.SendUsage(processesRes)
-}
\ No newline at end of file
+// Sample is what a collector read in one run, e.g. a cpu.CPU or a []proc.Process
+type Sample any
+
+// Collector reads one source of data. Collectors are registered once and
+// run on every tick of the refresh rate, or less often when their interval
+// is longer.
+type Collector interface {
+	Name() string
+	// Interval is the least time between two runs; 0 runs on every tick
+	Interval() time.Duration
+	Collect(ctx context.Context) (Sample, error)
+}
+
+// Snapshot holds the latest sample of every enabled collector by name
+type Snapshot map[string]Sample
+
+// How long a run of a collector may take before its context is cancelled,
+// unless the collector is given its own timeout
+const DEFAULT_TIMEOUT = 5 * time.Second
+
+type registration struct {
+	collector Collector
+	enabled   bool
+	interval  time.Duration
+	timeout   time.Duration
+	lastRun   time.Time
+	running   bool // a slow run is still going; the collector skips ticks until it ends
+	latest    Sample
+	hasSample bool
+	err       error // of the last run
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []*registration
+)
+
+// Register adds collectors, enabled and with their own interval
+func Register(collectors ...Collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, c := range collectors {
+		registry = append(registry, &registration{
+			collector: c,
+			enabled:   true,
+			interval:  c.Interval(),
+			timeout:   DEFAULT_TIMEOUT,
+		})
+	}
+}
+
+// Names lists the registered collectors in registration order
+func Names() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	names := make([]string, len(registry))
+	for i, r := range registry {
+		names[i] = r.collector.Name()
+	}
+
+	return names
+}
+
+// SetEnabled starts or stops running a collector. A disabled collector
+// drops out of snapshots.
+func SetEnabled(name string, enabled bool) error {
+	return withRegistration(name, func(r *registration) {
+		r.enabled = enabled
+
+		if !enabled {
+			r.latest, r.hasSample = nil, false
+		}
+	})
+}
+
+// SetInterval overrides how often a collector runs
+func SetInterval(name string, interval time.Duration) error {
+	return withRegistration(name, func(r *registration) {
+		r.interval = interval
+	})
+}
+
+// SetTimeout changes how long a run of a collector may take
+func SetTimeout(name string, timeout time.Duration) error {
+	return withRegistration(name, func(r *registration) {
+		r.timeout = timeout
+	})
+}
+
+// Err returns the error of the last run of a collector
+func Err(name string) error {
+	var err error
+
+	withRegistration(name, func(r *registration) {
+		err = r.err
+	})
+
+	return err
+}
+
+func withRegistration(name string, change func(*registration)) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, r := range registry {
+		if r.collector.Name() == name {
+			change(r)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown collector %q", name)
+}
+
+// Collect runs every enabled collector that is due, in parallel, each with
+// its own deadline, and returns the latest samples of all enabled
+// collectors. It waits for the runs at most until wait passes; a collector
+// still running then keeps its previous sample, and skips the following
+// ticks until its run ends. A collector that fails keeps its previous
+// sample too.
+func Collect(ctx context.Context, wait time.Duration) Snapshot {
+	registryMu.Lock()
+	due := make([]*registration, 0, len(registry))
+	now := time.Now()
+
+	for _, r := range registry {
+		if r.enabled && !r.running && (r.lastRun.IsZero() || now.Sub(r.lastRun) >= r.interval) {
+			r.running = true
+			due = append(due, r)
+		}
+	}
+	registryMu.Unlock()
+
+	var wg sync.WaitGroup
+
+	for _, r := range due {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			r.run(ctx, now)
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	snapshot := make(Snapshot, len(registry))
+	for _, r := range registry {
+		if r.enabled && r.hasSample {
+			snapshot[r.collector.Name()] = r.latest
+		}
+	}
+
+	return snapshot
+}
+
+// run collects one sample under the collector's deadline
+// collect runs the collector, turning a panic into its error. A panic here
+// would end the program from another goroutine than the UI's, with the
+// terminal still in raw mode.
+func (r *registration) collect(ctx context.Context) (sample Sample, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			sample, err = nil, fmt.Errorf("%s collector panicked: %v", r.collector.Name(), p)
+		}
+	}()
+
+	return r.collector.Collect(ctx)
+}
+
+func (r *registration) run(ctx context.Context, startedAt time.Time) {
+	registryMu.Lock()
+	timeout := r.timeout
+	registryMu.Unlock()
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sample, err := r.collect(runCtx)
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	r.running = false
+	r.lastRun = startedAt
+	r.err = err
+
+	// A collector disabled while it ran stays without a sample
+	if err == nil && r.enabled {
+		r.latest, r.hasSample = sample, true
+	}
+}
@@ -1,6 +1,7 @@
 package cpu
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"regexp"
@@ -8,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/amirdaraby/titop/internal/collect"
 	"github.com/amirdaraby/titop/internal/reader"
 )
 
@@ -45,18 +47,18 @@ const (
 )
 
 
-func SendUsage(res chan CPU) {
-
+// Usage samples every core, relative to the previous sample
+func Usage() (CPU, error) {
 	cpuStatContent, err := reader.ReadStat()
 
 	if err != nil {
-		panic(err)
+		return CPU{}, err
 	}
 
 	uptimeContent, err := reader.ReadUptime()
 
 	if err != nil {
-		panic(err)
+		return CPU{}, err
 	}
 
 	uptimeInSeconds := strings.Split(string(uptimeContent), " ")[0]
@@ -64,7 +66,7 @@ func SendUsage(res chan CPU) {
 	regex, err := regexp.Compile(`(?m)^cpu\d+.*$`)
 
 	if err != nil {
-		panic(err)
+		return CPU{}, err
 	}
 
 	coreLines := regex.FindAllString(string(cpuStatContent), -1)
@@ -78,7 +80,7 @@ func SendUsage(res chan CPU) {
 		for i := 1; i < len(spiltedData); i++ {
 			coreStats[i-1], err = strconv.Atoi(spiltedData[i])
 			if err != nil {
-				panic(err)
+				return CPU{}, err
 			}
 		}
 
@@ -88,17 +90,17 @@ func SendUsage(res chan CPU) {
 	}
 
 	if err != nil {
-		panic(err)
+		return CPU{}, err
 	}
 
 	cpu := calculateCpuCoresOverallUsage(currentCoreStatuses)
 	cpu.UpTime, err = time.ParseDuration(fmt.Sprintf("%s%s", uptimeInSeconds, "s"))
 
 	if err != nil {
-		panic(err)
+		return CPU{}, err
 	}
 
-	res <- cpu
+	return cpu, nil
 }
 
 func calculateCpuCoresOverallUsage(coreStats []cpuCoreOverallStat) CPU {
@@ -151,3 +153,20 @@ func overallCpuTotalTime(stat [10]int) int {
 func overallCpuIdleTime(stat [10]int) int {
 	return stat[IDLE_OVERALL_STAT] + stat[IOWAIT_OVERALL_STAT]
 }
+
+const NAME = "cpu"
+
+// Collector samples the usage of every core
+type Collector struct{}
+
+func (Collector) Name() string {
+	return NAME
+}
+
+func (Collector) Interval() time.Duration {
+	return 0
+}
+
+func (Collector) Collect(ctx context.Context) (collect.Sample, error) {
+	return Usage()
+}
@@ -0,0 +1,219 @@
+package disk
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/amirdaraby/titop/internal/collect"
+	"github.com/amirdaraby/titop/internal/reader"
+	"golang.org/x/sys/unix"
+)
+
+// Pseudo and in-memory filesystems, skipped unless every filesystem is asked for
+var skippedTypes = map[string]bool{
+	"proc":     true,
+	"sysfs":    true,
+	"tmpfs":    true,
+	"devtmpfs": true,
+	"overlay":  true,
+}
+
+// Fields of a /proc/self/mountinfo line; the filesystem type and source
+// follow the "-" separator after the optional fields
+const (
+	MOUNT_DEVICE      = 2
+	MOUNT_POINT       = 4
+	MOUNT_MIN_FIELDS  = 7
+	MOUNT_SEPARATOR   = "-"
+	MOUNT_TYPE_OFFSET = 1
+	MOUNT_SRC_OFFSET  = 2
+)
+
+var showAll atomic.Bool // toggled from the UI
+
+// Mount points whose statfs hasn't returned yet, such as those of an
+// unreachable NFS server. They are skipped until it does, rather than
+// piling up another blocked call every run.
+var hangingMounts sync.Map
+
+var errMountHanging = errors.New("statfs still hasn't returned")
+
+type Filesystem struct {
+	MountPoint string
+	Source     string // device or what the filesystem was mounted from
+	Type       string
+	Total      int64 // bytes
+	Used       int64 // bytes
+	Available  int64 // bytes available to unprivileged users
+	Usage      float32
+
+	Inodes, InodesUsed int64
+	InodeUsage         float32
+	HasInodes          bool // some filesystems, e.g. btrfs, don't count inodes
+}
+
+// SetShowAll makes pseudo, in-memory and empty filesystems listed too
+func SetShowAll(enabled bool) {
+	showAll.Store(enabled)
+}
+
+func ShowAllEnabled() bool {
+	return showAll.Load()
+}
+
+// Usage returns the usage of every mounted filesystem, each device once
+// even when it is mounted in several places
+func Usage(ctx context.Context) ([]Filesystem, error) {
+	var filesystems []Filesystem
+
+	mountInfoContent, err := reader.ReadMountInfo()
+
+	if err != nil {
+		return nil, err
+	}
+
+	all := showAll.Load()
+	seenDevices := make(map[string]bool)
+
+	for _, line := range strings.Split(string(mountInfoContent), "\n") {
+		// Stop before the next mount once the deadline passed
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(line)
+
+		if len(fields) < MOUNT_MIN_FIELDS {
+			continue
+		}
+
+		separator := -1
+		for i := MOUNT_MIN_FIELDS - 1; i < len(fields); i++ {
+			if fields[i] == MOUNT_SEPARATOR {
+				separator = i
+				break
+			}
+		}
+
+		if separator < 0 || separator+MOUNT_SRC_OFFSET >= len(fields) {
+			continue
+		}
+
+		fsType := fields[separator+MOUNT_TYPE_OFFSET]
+		device := fields[MOUNT_DEVICE]
+
+		if (!all && skippedTypes[fsType]) || seenDevices[device] {
+			continue
+		}
+
+		mountPoint := unescapeMountPath(fields[MOUNT_POINT])
+
+		// Mount points we can't reach, e.g. of other mount namespaces or
+		// hanging network filesystems, are skipped
+		stat, err := statfs(ctx, mountPoint)
+		if err != nil || (!all && stat.Blocks == 0) {
+			continue
+		}
+
+		seenDevices[device] = true
+
+		blockSize := int64(stat.Bsize)
+		fs := Filesystem{
+			MountPoint: mountPoint,
+			Source:     unescapeMountPath(fields[separator+MOUNT_SRC_OFFSET]),
+			Type:       fsType,
+			Total:      int64(stat.Blocks) * blockSize,
+			Used:       int64(stat.Blocks-stat.Bfree) * blockSize,
+			Available:  int64(stat.Bavail) * blockSize,
+			Inodes:     int64(stat.Files),
+			InodesUsed: int64(stat.Files - stat.Ffree),
+			HasInodes:  stat.Files > 0,
+		}
+
+		// Like df, blocks reserved for root don't count as available
+		if capacity := fs.Used + fs.Available; capacity > 0 {
+			fs.Usage = float32(fs.Used) / float32(capacity) * 100
+		}
+
+		if fs.HasInodes {
+			fs.InodeUsage = float32(fs.InodesUsed) / float32(fs.Inodes) * 100
+		}
+
+		filesystems = append(filesystems, fs)
+	}
+
+	return filesystems, nil
+}
+
+// statfs reads the usage of a filesystem, giving up on it when ctx is done
+// first; statfs on a network filesystem blocks while its server is away
+func statfs(ctx context.Context, mountPoint string) (unix.Statfs_t, error) {
+	if _, hanging := hangingMounts.LoadOrStore(mountPoint, true); hanging {
+		return unix.Statfs_t{}, errMountHanging
+	}
+
+	type result struct {
+		stat unix.Statfs_t
+		err  error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		stat, err := reader.ReadStatfs(mountPoint)
+		hangingMounts.Delete(mountPoint)
+		done <- result{stat, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.stat, r.err
+	case <-ctx.Done():
+		return unix.Statfs_t{}, ctx.Err()
+	}
+}
+
+// unescapeMountPath decodes the octal escapes mountinfo uses for spaces,
+// tabs, newlines and backslashes in paths
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, "\\") {
+		return path
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+4 <= len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+
+		b.WriteByte(path[i])
+	}
+
+	return b.String()
+}
+
+const NAME = "filesystems"
+
+// Collector samples the usage of every mounted filesystem
+type Collector struct{}
+
+func (Collector) Name() string {
+	return NAME
+}
+
+func (Collector) Interval() time.Duration {
+	return 0
+}
+
+func (Collector) Collect(ctx context.Context) (collect.Sample, error) {
+	return Usage(ctx)
+}
@@ -1,15 +1,18 @@
 package mem
 
 import (
+	"context"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/amirdaraby/titop/internal/collect"
 	"github.com/amirdaraby/titop/internal/reader"
 )
 
 type Memory struct {
 	Usage                       float32
-	Total, Available, Allocated int // KB
+	Total, Available, Allocated int // KiB
 	Swap                        *Memory
 }
 
@@ -19,11 +22,11 @@ const (
 	VM_SWAP_MEM = 25
 )
 
-func SendUsage(res chan Memory) {
+func Usage() (Memory, error) {
 	memInfoContent, err := reader.ReadMemInfo()
 
 	if err != nil {
-		panic(err)
+		return Memory{}, err
 	}
 
 	memInfoLines := strings.Split(string(memInfoContent), "\n")
@@ -43,7 +46,7 @@ func SendUsage(res chan Memory) {
 		value, err := strconv.Atoi(valStr)
 
 		if err != nil {
-			panic(err)
+			return Memory{}, err
 		}
 
 		memInfoMap[key] = value
@@ -70,11 +73,28 @@ func SendUsage(res chan Memory) {
 		}
 	}
 
-	res <- Memory{
+	return Memory{
 		Usage:     usage,
 		Total:     total,
 		Available: available,
 		Allocated: allocated,
 		Swap:      swap,
-	}
-}
\ No newline at end of file
+	}, nil
+}
+
+const NAME = "memory"
+
+// Collector samples memory and swap usage
+type Collector struct{}
+
+func (Collector) Name() string {
+	return NAME
+}
+
+func (Collector) Interval() time.Duration {
+	return 0
+}
+
+func (Collector) Collect(ctx context.Context) (collect.Sample, error) {
+	return Usage()
+}
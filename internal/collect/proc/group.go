@@ -0,0 +1,92 @@
+package proc
+
+import "sort"
+
+// Group sums the usage of processes sharing a key, such as an owner or a command.
+type Group struct {
+	Name                string
+	Processes           []Process
+	CpuUsage, MemUsage  float32
+	Resident            int64 // bytes
+	ReadRate, WriteRate int64 // bytes per second
+}
+
+// GroupBy groups processes by key, busiest group first. Exited processes are
+// left out since they no longer use anything.
+func GroupBy(processes []Process, key func(Process) string) []Group {
+	indexes := make(map[string]int)
+	var groups []Group
+
+	for _, p := range processes {
+		if p.Exited {
+			continue
+		}
+
+		name := key(p)
+
+		idx, exists := indexes[name]
+		if !exists {
+			idx = len(groups)
+			indexes[name] = idx
+			groups = append(groups, Group{Name: name})
+		}
+
+		g := &groups[idx]
+		g.Processes = append(g.Processes, p)
+		g.CpuUsage += p.CpuUsage
+		g.MemUsage += p.MemUsage
+		g.Resident += p.Memory.Resident
+		g.ReadRate += p.IO.ReadRate
+		g.WriteRate += p.IO.WriteRate
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].CpuUsage != groups[j].CpuUsage {
+			return groups[i].CpuUsage > groups[j].CpuUsage
+		}
+
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups
+}
+
+// Summary sums the group into a single Process, so it can be listed like one.
+// User is kept only when every process in the group shares it.
+func (g Group) Summary() Process {
+	summary := Process{Command: g.Name}
+
+	for i, p := range g.Processes {
+		if i == 0 {
+			summary.UID = p.UID
+			summary.User = p.User
+		} else if summary.UID != p.UID {
+			summary.UID = ""
+			summary.User = "*"
+		}
+
+		summary.CpuUsage += p.CpuUsage
+		summary.MemUsage += p.MemUsage
+		summary.ThreadCount += p.ThreadCount
+		summary.CpuTime += p.CpuTime
+
+		summary.Memory.Virtual += p.Memory.Virtual
+		summary.Memory.Resident += p.Memory.Resident
+		summary.Memory.Shared += p.Memory.Shared
+		summary.Memory.Swap += p.Memory.Swap
+		summary.Memory.Proportional += p.Memory.Proportional
+		summary.Memory.Unique += p.Memory.Unique
+		summary.Memory.HasRollup = summary.Memory.HasRollup || p.Memory.HasRollup
+
+		summary.IO.ReadRate += p.IO.ReadRate
+		summary.IO.WriteRate += p.IO.WriteRate
+		summary.IO.ReadTotal += p.IO.ReadTotal
+		summary.IO.WriteTotal += p.IO.WriteTotal
+		summary.IO.CancelledWriteTotal += p.IO.CancelledWriteTotal
+		summary.IO.ReadSyscalls += p.IO.ReadSyscalls
+		summary.IO.WriteSyscalls += p.IO.WriteSyscalls
+		summary.IO.Readable = summary.IO.Readable || p.IO.Readable
+	}
+
+	return summary
+}
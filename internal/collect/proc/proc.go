@@ -1,29 +1,111 @@
 package proc
 
 import (
+	"context"
+	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/amirdaraby/titop/internal/collect"
 	"github.com/amirdaraby/titop/internal/reader"
 	"github.com/amirdaraby/titop/internal/shared"
+	"github.com/amirdaraby/titop/internal/users"
 )
 
-var processLastStates map[string]processStat = make(map[string]processStat)
+var processLastStates map[processKey]processStat = make(map[processKey]processStat)
+var lastProcesses map[processKey]Process = make(map[processKey]Process)
+var exitedProcesses map[processKey]exitedProcess = make(map[processKey]exitedProcess)
+
+// Toggled from the UI while the collector runs
+var readSmapsRollup atomic.Bool
+var readThreads atomic.Bool
+var readCommandLines atomic.Bool
+
+// How long an exited process keeps being reported before it is dropped
+const EXITED_LINGER = 3 * time.Second
+
+// State of a process that exited but wasn't waited for by its parent yet
+const ZOMBIE_STATE = "Z"
+
+// Flag of stat set for threads the kernel runs itself
+const PF_KTHREAD = 0x00200000
 
 type Process struct {
 	ID       string
 	Command  string
 	State    string
 	Priority string
+	Nice     string
+	ParentID string
 	CpuUsage float32
 	MemUsage float32
-	IO       int64 // bytes
+	IO       ProcessIO
+	Memory   ProcessMemory
+	Exited   bool // no longer in /proc; this is its last known state
+
+	Args         []string // command line while it is being read; empty for kernel threads and zombies
+	KernelThread bool
+
+	ThreadCount int
+	CpuTime     time.Duration // user and system time used since it started
+	StartedAt   time.Time
+	TTY         string // controlling terminal, empty when it has none
+
+	// Wait status of a zombie, as its parent will get it from wait(2). The
+	// kernel shows it only to whoever may ptrace the process.
+	ExitCode    int
+	HasExitCode bool
+
+	UID    string
+	User   string // resolved from UID; the UID itself when it has no passwd entry
+	Cgroup string // cgroup2 path, empty on cgroup v1 only systems
+
+	Namespaces   Namespaces
+	NamespacePID string    // PID inside the process's own PID namespace, from NSpid
+	Processor    int       // CPU the task last ran on
+	ThreadOf     string    // ID of the owning process when this is a thread
+	Threads      []Process // only read while the thread view is enabled
+}
+
+// Namespaces holds namespace inode numbers, empty when the links can't be read
+type Namespaces struct {
+	PID, Net, Mnt string
+}
+
+type ProcessIO struct {
+	ReadRate, WriteRate         int64 // bytes per second
+	ReadTotal, WriteTotal       int64 // bytes since the process started
+	CancelledWriteTotal         int64 // bytes
+	ReadSyscalls, WriteSyscalls int64
+	Readable                    bool // false when /proc/[pid]/io could not be read
+}
+
+type ProcessMemory struct {
+	Virtual, Resident, Shared, Swap int64 // bytes
+	Proportional, Unique            int64 // bytes, valid only when HasRollup is set
+	HasRollup                       bool
+}
+
+// processKey identifies a process across samples. The start time tells a
+// reused PID apart from the process that held it before.
+type processKey struct {
+	pid       string
+	startTime int64
+	threadOf  string
+}
+
+type exitedProcess struct {
+	process  Process
+	exitedAt time.Time
 }
 
 type processStat struct {
-	uTime, sTime, startTime, systemUptime, readBytes, writeBytes int64
+	uTime, sTime, startTime, readBytes, writeBytes int64
+	sampledAt                                      time.Time
 }
 
 const (
@@ -84,168 +166,553 @@ const (
 const (
 	M_VSIZE_PROCESS = iota
 	M_RSS_PROCESS
-	M_SWAP_SHARED_PROCESS
-	M_SWAP_TEXT_PROCESS
-	M_SWAP_LIBRARY_PROCESS
-	M_SWAP_DATA_PROCESS
-	M_SWAP_DIRTY_PROCESS
+	M_SHARED_PROCESS
+	M_TEXT_PROCESS
+	M_LIBRARY_PROCESS
+	M_DATA_PROCESS
+	M_DIRTY_PROCESS
 )
 
 func (p *processStat) processTime() int64 {
 	return p.uTime + p.sTime
 }
 
-func SendUsage(res chan []Process) {
-	processesContent := reader.ReadProcesses()
+// SetSmapsRollup toggles reading /proc/[pid]/smaps_rollup for PSS and USS.
+// It is off by default because the kernel walks every mapping to produce it.
+func SetSmapsRollup(enabled bool) {
+	readSmapsRollup.Store(enabled)
+}
+
+func SmapsRollupEnabled() bool {
+	return readSmapsRollup.Load()
+}
+
+// SetThreads toggles reading /proc/[pid]/task for every process.
+func SetThreads(enabled bool) {
+	readThreads.Store(enabled)
+}
+
+func ThreadsEnabled() bool {
+	return readThreads.Load()
+}
+
+// SetCommandLines toggles reading /proc/[pid]/cmdline for every process.
+func SetCommandLines(enabled bool) {
+	readCommandLines.Store(enabled)
+}
+
+func CommandLinesEnabled() bool {
+	return readCommandLines.Load()
+}
+
+func Usage(ctx context.Context) ([]Process, error) {
+	var extraFiles []string
+	if readSmapsRollup.Load() {
+		extraFiles = append(extraFiles, "smaps_rollup")
+	}
+	if readCommandLines.Load() {
+		extraFiles = append(extraFiles, "cmdline")
+	}
+
+	processesContent := reader.ReadProcesses(extraFiles...)
+	users.Refresh()
 
 	var processes []Process
 	seenPIDs := make(map[string]struct{})
+	currentStates := make(map[processKey]processStat)
+	currentProcesses := make(map[processKey]Process)
 
-	systemUptime, err := shared.GetUptime()
-	if err != nil {
-		panic(err)
+	for _, content := range processesContent {
+		// Give up once the run is past its deadline
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		process, key, err := sample(content, "", currentStates)
+
+		// A process exiting while it is read leaves a file half written;
+		// the rest of the sample still stands
+		if err != nil {
+			continue
+		}
+
+		if _, exists := seenPIDs[process.ID]; exists {
+			continue
+		}
+
+		seenPIDs[process.ID] = struct{}{}
+
+		if readThreads.Load() {
+			process.Threads = sampleThreads(process, currentStates)
+		}
+
+		currentProcesses[key] = process
+		processes = append(processes, process)
 	}
 
-	numCores := shared.GetConfig().CoresCount
+	processes = append(processes, collectExited(currentProcesses, time.Now())...)
 
-	for _, p := range processesContent {
-		stats := strings.Split(string(p["stat"]), " ")
+	// Keep the /proc listing order with exited processes slotted in
+	sort.SliceStable(processes, func(i, j int) bool {
+		return processes[i].ID < processes[j].ID
+	})
 
-		pid := stats[ID_PROCESS]
-		if _, exists := seenPIDs[pid]; exists {
+	// Replacing the maps evicts every process that was not seen in this scan
+	processLastStates = currentStates
+	lastProcesses = currentProcesses
+
+	return processes, nil
+}
+
+// collectExited records processes that disappeared since the last scan and
+// returns those that exited within EXITED_LINGER.
+func collectExited(current map[processKey]Process, now time.Time) []Process {
+	for key, process := range lastProcesses {
+		if _, alive := current[key]; alive {
 			continue
 		}
 
-		seenPIDs[pid] = struct{}{}
+		process.Exited = true
+		process.CpuUsage = 0
+		process.IO.ReadRate = 0
+		process.IO.WriteRate = 0
 
-		cmd := stats[COMM_PROCESS]
-		priority := stats[PRIORITY_PROCESS]
-		state := stats[STATE_PROCESS]
+		exitedProcesses[key] = exitedProcess{process: process, exitedAt: now}
+	}
 
-		utime, err := strconv.Atoi(stats[UTIME_PROCESS])
-		if err != nil {
-			panic(err)
+	var exited []Process
+
+	for key, e := range exitedProcesses {
+		if now.Sub(e.exitedAt) >= EXITED_LINGER {
+			delete(exitedProcesses, key)
+			continue
 		}
 
-		stime, err := strconv.Atoi(stats[STIME_PROCESS])
+		exited = append(exited, e.process)
+	}
+
+	return exited
+}
+
+// sample parses one process or thread and computes its rates against the
+// previous sample with the same key. threadOf is empty for processes.
+func sample(content reader.ProcessContent, threadOf string, currentStates map[processKey]processStat) (Process, processKey, error) {
+	p := content.Files
+	stats := splitStat(p["stat"])
+
+	if len(stats) <= START_TIME_PROCESS {
+		return Process{}, processKey{}, fmt.Errorf("malformed stat: %q", p["stat"])
+	}
+
+	numCores := shared.GetConfig().CoresCount
+	clkTck := float64(shared.GetConfig().ClkTck)
+
+	pid := stats[ID_PROCESS]
+	cmd := stats[COMM_PROCESS]
+	priority := stats[PRIORITY_PROCESS]
+	nice := stats[NICE_PROCESS]
+	parentID := stats[PARENT_ID_PROCESS]
+	state := stats[STATE_PROCESS]
+
+	threadCount, err := strconv.Atoi(stats[NUM_THREADS_PROCESS])
+	if err != nil {
+		return Process{}, processKey{}, err
+	}
+
+	ttyNr, err := strconv.Atoi(stats[TTY_NR_PROCESS])
+	if err != nil {
+		return Process{}, processKey{}, err
+	}
+
+	flags, err := strconv.ParseUint(stats[FLAGS_PROCESS], 10, 64)
+	if err != nil {
+		return Process{}, processKey{}, err
+	}
+
+	utime, err := strconv.Atoi(stats[UTIME_PROCESS])
+	if err != nil {
+		return Process{}, processKey{}, err
+	}
+
+	stime, err := strconv.Atoi(stats[STIME_PROCESS])
+	if err != nil {
+		return Process{}, processKey{}, err
+	}
+
+	startTime, err := strconv.Atoi(stats[START_TIME_PROCESS])
+	if err != nil {
+		return Process{}, processKey{}, err
+	}
+
+	processor := -1
+	if len(stats) > PROCESSOR_PROCESS {
+		processor, err = strconv.Atoi(stats[PROCESSOR_PROCESS])
 		if err != nil {
-			panic(err)
+			return Process{}, processKey{}, err
 		}
+	}
+
+	// Threads share the memory and owner of their process, so only processes report them
+	var memory ProcessMemory
+	var memUsage float32
+	var uid, user, cgroup, namespacePID string
+	var namespaces Namespaces
+
+	if threadOf == "" {
+		uid = parseUID(p)
+		user = users.Lookup(uid)
+		cgroup = parseCgroup(p)
+		namespaces = parseNamespaces(p)
+		namespacePID = parseNamespacePID(p)
+
+		memory, err = parseMemory(p)
 
-		startTime, err := strconv.Atoi(stats[START_TIME_PROCESS])
 		if err != nil {
-			panic(err)
+			return Process{}, processKey{}, err
 		}
 
-		mstats := strings.Split(string(p["statm"]), " ")
+		memUsage = float32(memory.Resident) / float32(shared.GetConfig().TotalMem) * 100
+	}
+
+	io, err := parseIO(p)
+
+	if err != nil {
+		return Process{}, processKey{}, err
+	}
+
+	currentStat := processStat{
+		uTime:      int64(utime),
+		sTime:      int64(stime),
+		startTime:  int64(startTime),
+		sampledAt:  content.ReadAt,
+		readBytes:  io.ReadTotal,
+		writeBytes: io.WriteTotal,
+	}
+
+	key := processKey{pid: pid, startTime: int64(startTime), threadOf: threadOf}
 
-		rss, err := strconv.Atoi(mstats[M_RSS_PROCESS])
+	// A new process, or a new one reusing the PID, has no previous sample to compare against
+	lastStat, exists := processLastStates[key]
+	if !exists {
+		lastStat = currentStat
+	}
+
+	processTimeDiff := float64(currentStat.processTime() - lastStat.processTime())
+
+	// Exact interval between the two samples of this process, in seconds
+	elapsed := currentStat.sampledAt.Sub(lastStat.sampledAt).Seconds()
+
+	var cpuUsage float32
+	if elapsed > 0 {
+		cpuUsage = float32((processTimeDiff / clkTck / elapsed) * 100.0 / float64(numCores))
+	}
+
+	if cpuUsage < 0 || math.IsNaN(float64(cpuUsage)) {
+		cpuUsage = 0
+	} else if cpuUsage > 100.0 {
+		cpuUsage = 100.0
+	}
+
+	if io.Readable && elapsed > 0 {
+		readD := io.ReadTotal - lastStat.readBytes
+		writeD := io.WriteTotal - lastStat.writeBytes
+
+		io.ReadRate = int64(float64(readD) / elapsed)
+		io.WriteRate = int64(float64(writeD) / elapsed)
+	}
+
+	currentStates[key] = currentStat
 
+	// io is readable under the same ptrace check that reveals the exit code;
+	// it reads as 0 otherwise
+	var exitCode int
+	hasExitCode := state == ZOMBIE_STATE && io.Readable && len(stats) > EXIT_CODE_PROCESS
+	if hasExitCode {
+		exitCode, err = strconv.Atoi(stats[EXIT_CODE_PROCESS])
+		hasExitCode = err == nil
+	}
+
+	return Process{
+		ID:       pid,
+		Command:  cmd,
+		State:    state,
+		Priority: priority,
+		Nice:     nice,
+		ParentID: parentID,
+		Args:     parseArgs(p),
+		CpuUsage: cpuUsage,
+		MemUsage: memUsage,
+		IO:       io,
+		Memory:   memory,
+		UID:      uid,
+		User:     user,
+		Cgroup:   cgroup,
+
+		ThreadCount: threadCount,
+		CpuTime:     time.Duration(float64(utime+stime) / clkTck * float64(time.Second)),
+		StartedAt:   shared.GetConfig().BootTime.Add(time.Duration(float64(startTime) / clkTck * float64(time.Second))),
+		TTY:         ttyName(ttyNr),
+
+		KernelThread: flags&PF_KTHREAD != 0,
+
+		Namespaces:   namespaces,
+		NamespacePID: namespacePID,
+		Processor:    processor,
+		ThreadOf:     threadOf,
+		ExitCode:     exitCode,
+		HasExitCode:  hasExitCode,
+	}, key, nil
+}
+
+// sampleThreads returns the threads of a multi-threaded process. A process
+// with a single thread returns nil, as that thread is the process itself.
+func sampleThreads(process Process, currentStates map[processKey]processStat) []Process {
+	threadsContent := reader.ReadThreads(process.ID)
+
+	if len(threadsContent) < 2 {
+		return nil
+	}
+
+	var threads []Process
+
+	for _, content := range threadsContent {
+		thread, _, err := sample(content, process.ID, currentStates)
+
+		// Threads come and go between listing the task directory and reading it
 		if err != nil {
-			panic(err)
+			continue
 		}
 
-		memAlloc := int64(rss) * shared.GetConfig().PageSize
+		thread.UID = process.UID
+		thread.User = process.User
+		thread.Cgroup = process.Cgroup
+		thread.Namespaces = process.Namespaces
+
+		threads = append(threads, thread)
+	}
+
+	return threads
+}
+
+// splitStat splits a stat file into fields. The comm field is taken out of
+// its parentheses whole, even when the name contains spaces or parentheses.
+func splitStat(content []byte) []string {
+	stat := string(content)
 
-		memUsage := float32(memAlloc) / float32(shared.GetConfig().TotalMem) * 100
+	commStart := strings.IndexByte(stat, '(')
+	commEnd := strings.LastIndexByte(stat, ')')
 
-		ioContent, ioExists := p["io"]
+	if commStart < 0 || commEnd < commStart {
+		return strings.Fields(stat)
+	}
+
+	fields := []string{strings.TrimSpace(stat[:commStart]), stat[commStart+1 : commEnd]}
 
-		var ioBytes int64 = -1
-		var readBytes int64 = 0
-		var writeBytes int64 = 0
+	return append(fields, strings.Fields(stat[commEnd+1:])...)
+}
 
-		if ioExists {
-			ioStatLines := strings.Split(string(ioContent), "\n")
-			ioStatMap := make(map[string]int64)
+// ttyName names a terminal from the device number in stat, as ps does for
+// the usual kinds of terminal
+func ttyName(nr int) string {
+	if nr == 0 {
+		return ""
+	}
 
-			for _, line := range ioStatLines {
-				seperatedLine := strings.Split(line, ":")
+	major := (nr >> 8) & 0xfff
+	minor := (nr & 0xff) | ((nr >> 12) & 0xfff00)
 
-				if len(seperatedLine) != 2 {
-					continue
-				}
+	switch {
+	case major >= 136 && major <= 143:
+		return fmt.Sprintf("pts/%d", minor+(major-136)*256)
+	case major == 4 && minor < 64:
+		return fmt.Sprintf("tty%d", minor)
+	case major == 4:
+		return fmt.Sprintf("ttyS%d", minor-64)
+	}
 
-				key := seperatedLine[0]
-				valStr := strings.Fields(seperatedLine[1])[0]
+	return fmt.Sprintf("%d:%d", major, minor)
+}
 
-				value, err := strconv.ParseInt(valStr, 10, 64)
+// parseArgs splits the NUL-separated arguments of /proc/[pid]/cmdline
+func parseArgs(files map[string][]byte) []string {
+	cmdline := strings.TrimRight(string(files["cmdline"]), "\x00")
 
-				if err != nil {
-					panic(err)
-				}
+	if cmdline == "" {
+		return nil
+	}
 
-				ioStatMap[key] = value
-			}
+	return strings.Split(cmdline, "\x00")
+}
 
-			readBytes = ioStatMap["read_bytes"]
-			writeBytes = ioStatMap["write_bytes"]
-			ioBytes = 0
-		}
+// parseIO reads /proc/[pid]/io. Readable stays false when the file could not be
+// read, which usually means the process belongs to another user.
+func parseIO(files map[string][]byte) (ProcessIO, error) {
+	var io ProcessIO
 
-		currentStat := processStat{
-			uTime:        int64(utime),
-			sTime:        int64(stime),
-			startTime:    int64(startTime),
-			systemUptime: systemUptime,
-			readBytes:    readBytes,
-			writeBytes:   writeBytes,
+	ioContent, exists := files["io"]
+	if !exists {
+		return io, nil
+	}
+
+	ioStatMap := make(map[string]int64)
+
+	for key, valStr := range parseKeyValues(ioContent) {
+		value, err := strconv.ParseInt(valStr, 10, 64)
+
+		if err != nil {
+			return io, err
 		}
 
-		lastStat, exists := processLastStates[pid]
-		if !exists {
-			lastStat = currentStat
-			processLastStates[pid] = currentStat
-			processes = append(processes, Process{
-				ID:       pid,
-				Command:  cmd,
-				State:    state,
-				Priority: priority,
-				CpuUsage: 0,
-				MemUsage: memUsage,
-				IO:       ioBytes,
-			})
-			continue
+		ioStatMap[key] = value
+	}
+
+	io.ReadTotal = ioStatMap["read_bytes"]
+	io.WriteTotal = ioStatMap["write_bytes"]
+	io.CancelledWriteTotal = ioStatMap["cancelled_write_bytes"]
+	io.ReadSyscalls = ioStatMap["syscr"]
+	io.WriteSyscalls = ioStatMap["syscw"]
+	io.Readable = true
+
+	return io, nil
+}
+
+func parseMemory(files map[string][]byte) (ProcessMemory, error) {
+	var memory ProcessMemory
+
+	mstats := strings.Fields(string(files["statm"]))
+	pageSize := shared.GetConfig().PageSize
+
+	pages := make([]int64, M_SHARED_PROCESS+1)
+	for i := range pages {
+		value, err := strconv.ParseInt(mstats[i], 10, 64)
+		if err != nil {
+			return memory, err
 		}
+		pages[i] = value
+	}
+
+	memory.Virtual = pages[M_VSIZE_PROCESS] * pageSize
+	memory.Resident = pages[M_RSS_PROCESS] * pageSize
+	memory.Shared = pages[M_SHARED_PROCESS] * pageSize
+
+	if statusContent, exists := files["status"]; exists {
+		memory.Swap = kibField(parseKeyValues(statusContent), "VmSwap")
+	}
+
+	if rollupContent, exists := files["smaps_rollup"]; exists {
+		rollup := parseKeyValues(rollupContent)
+		memory.Proportional = kibField(rollup, "Pss")
+		memory.Unique = kibField(rollup, "Private_Clean") + kibField(rollup, "Private_Dirty")
+		memory.HasRollup = true
+	}
 
-		processTimeDiff := float64(currentStat.processTime() - lastStat.processTime())
+	return memory, nil
+}
 
-		uptimeDiff := float64(systemUptime-lastStat.systemUptime) * float64(shared.GetConfig().ClkTck)
+// parseUID returns the real uid from the Uid line of /proc/[pid]/status
+func parseUID(files map[string][]byte) string {
+	statusContent, exists := files["status"]
+	if !exists {
+		return ""
+	}
 
-		var cpuUsage float32
-		if uptimeDiff > 0 {
-			cpuUsage = float32((processTimeDiff / uptimeDiff) * 100.0 / float64(numCores))
+	fields := strings.Fields(parseKeyValues(statusContent)["Uid"])
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return fields[0]
+}
+
+// parseCgroup returns the cgroup2 path from the "0::<path>" line of /proc/[pid]/cgroup
+func parseCgroup(files map[string][]byte) string {
+	for _, line := range strings.Split(string(files["cgroup"]), "\n") {
+		if cgroup, found := strings.CutPrefix(line, "0::"); found {
+			return cgroup
 		}
+	}
+
+	return ""
+}
 
-		if cpuUsage < 0 || math.IsNaN(float64(cpuUsage)) {
-			cpuUsage = 0
-		} else if cpuUsage > 100.0 {
-			cpuUsage = 100.0
+// parseNamespaces takes the inode numbers out of links like "net:[4026531833]"
+func parseNamespaces(files map[string][]byte) Namespaces {
+	inode := func(name string) string {
+		link := string(files["ns/"+name])
+		start := strings.IndexByte(link, '[')
+
+		if start < 0 || !strings.HasSuffix(link, "]") {
+			return ""
 		}
 
-		if ioBytes != -1 {
-			readD := readBytes - lastStat.readBytes
-			writeD := writeBytes - lastStat.writeBytes
+		return link[start+1 : len(link)-1]
+	}
+
+	return Namespaces{
+		PID: inode("pid"),
+		Net: inode("net"),
+		Mnt: inode("mnt"),
+	}
+}
 
-			elapsedTime := time.Now().Sub(shared.GetLastRefresh()).Seconds()
+// parseNamespacePID returns the innermost PID from the NSpid line of
+// /proc/[pid]/status, which lists the PID in every nested PID namespace
+func parseNamespacePID(files map[string][]byte) string {
+	fields := strings.Fields(parseKeyValues(files["status"])["NSpid"])
 
-			if elapsedTime >= 1 {
-				ioBytes = (readD + writeD) / int64(elapsedTime)
-			}
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return fields[len(fields)-1]
+}
+
+// parseKeyValues parses "Key:   value" files such as status and smaps_rollup.
+func parseKeyValues(content []byte) map[string]string {
+	values := make(map[string]string)
+
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, found := strings.Cut(line, ":")
+
+		if !found {
+			continue
 		}
 
-		processLastStates[pid] = currentStat
+		values[key] = strings.TrimSpace(value)
+	}
+
+	return values
+}
+
+// kibField returns a "<n> kB" field in bytes, or 0 when it is missing.
+func kibField(values map[string]string, key string) int64 {
+	fields := strings.Fields(values[key])
 
-		processes = append(processes, Process{
-			ID:       pid,
-			Command:  cmd,
-			State:    state,
-			Priority: priority,
-			CpuUsage: cpuUsage,
-			MemUsage: memUsage,
-			IO:       ioBytes,
-		})
+	if len(fields) == 0 {
+		return 0
 	}
 
-	res <- processes
+	value, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return value * 1024
+}
+
+const NAME = "processes"
+
+// Collector samples every process
+type Collector struct{}
+
+func (Collector) Name() string {
+	return NAME
+}
+
+func (Collector) Interval() time.Duration {
+	return 0
+}
+
+func (Collector) Collect(ctx context.Context) (collect.Sample, error) {
+	return Usage(ctx)
 }
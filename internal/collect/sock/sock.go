@@ -0,0 +1,267 @@
+package sock
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/amirdaraby/titop/internal/reader"
+)
+
+type Socket struct {
+	Protocol  string // tcp, tcp6, udp, udp6 or unix
+	Local     string // address:port, or the path of a unix socket
+	Remote    string // address:port, empty for unix sockets
+	State     string
+	Inode     string
+	UID       string // empty for unix sockets, the table doesn't list it
+	Listening bool
+}
+
+// Listener is a listening TCP or UDP socket and the processes holding it.
+// Forked servers often share one listening socket between several processes.
+type Listener struct {
+	Socket
+	PIDs []string
+}
+
+var inetTables = []string{"tcp", "tcp6", "udp", "udp6"}
+
+// TCP states as numbered in include/net/tcp_states.h
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+	"0C": "NEW_SYN_RECV",
+}
+
+const (
+	TCP_LISTEN_STATE      = "0A"
+	UDP_UNCONNECTED_STATE = "07"
+	UNIX_ACCEPTING_FLAG   = 0x10000 // __SO_ACCEPTCON
+	UNIX_STREAM_TYPE      = "0001"
+	UNIX_DGRAM_TYPE       = "0002"
+	UNIX_SEQPACKET_TYPE   = "0005"
+	UNIX_CONNECTED_STATE  = "03"
+	INET_LOCAL_ADDRESS    = 1
+	INET_REMOTE_ADDRESS   = 2
+	INET_STATE            = 3
+	INET_UID              = 7
+	INET_INODE            = 9
+	UNIX_FLAGS            = 3
+	UNIX_TYPE             = 4
+	UNIX_STATE            = 5
+	UNIX_INODE            = 6
+	UNIX_PATH             = 7
+)
+
+// Sockets returns the sockets of the network namespace of pid, keyed by inode.
+// An empty pid reads the namespace titop runs in. Tables that can't be read,
+// e.g. tcp6 with IPv6 disabled, are skipped.
+func Sockets(pid string) map[string]Socket {
+	sockets := make(map[string]Socket)
+
+	for _, table := range inetTables {
+		content, err := reader.ReadNet(pid, table)
+
+		if err != nil {
+			continue
+		}
+
+		for _, s := range parseInetTable(table, content) {
+			sockets[s.Inode] = s
+		}
+	}
+
+	if content, err := reader.ReadNet(pid, "unix"); err == nil {
+		for _, s := range parseUnixTable(content) {
+			sockets[s.Inode] = s
+		}
+	}
+
+	return sockets
+}
+
+// Listening returns every listening TCP and UDP socket of titop's network
+// namespace, sorted by port. Owners are found by scanning the file
+// descriptors of every process for the socket's inode, so sockets of
+// processes we may not inspect have no PIDs.
+func Listening() []Listener {
+	var listeners []Listener
+	indexes := make(map[string]int)
+
+	for _, table := range inetTables {
+		content, err := reader.ReadNet("", table)
+
+		if err != nil {
+			continue
+		}
+
+		for _, s := range parseInetTable(table, content) {
+			if s.Listening {
+				indexes[s.Inode] = len(listeners)
+				listeners = append(listeners, Listener{Socket: s})
+			}
+		}
+	}
+
+	for _, pid := range reader.ReadPIDs() {
+		targets, err := reader.ReadFds(pid)
+
+		if err != nil {
+			continue
+		}
+
+		for _, target := range targets {
+			inode, found := strings.CutPrefix(target, "socket:[")
+			if !found {
+				continue
+			}
+
+			if idx, exists := indexes[strings.TrimSuffix(inode, "]")]; exists {
+				listeners[idx].PIDs = append(listeners[idx].PIDs, pid)
+			}
+		}
+	}
+
+	sort.SliceStable(listeners, func(i, j int) bool {
+		return port(listeners[i].Local) < port(listeners[j].Local)
+	})
+
+	return listeners
+}
+
+func port(address string) int {
+	_, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return 0
+	}
+
+	p, _ := strconv.Atoi(portStr)
+
+	return p
+}
+
+func parseInetTable(protocol string, content []byte) []Socket {
+	var sockets []Socket
+	isTCP := strings.HasPrefix(protocol, "tcp")
+
+	// The first line is a header
+	for _, line := range strings.Split(string(content), "\n")[1:] {
+		fields := strings.Fields(line)
+
+		if len(fields) <= INET_INODE {
+			continue
+		}
+
+		state := fields[INET_STATE]
+
+		s := Socket{
+			Protocol: protocol,
+			Local:    parseInetAddress(fields[INET_LOCAL_ADDRESS]),
+			Remote:   parseInetAddress(fields[INET_REMOTE_ADDRESS]),
+			Inode:    fields[INET_INODE],
+			UID:      fields[INET_UID],
+		}
+
+		if isTCP {
+			s.State = tcpStates[state]
+			s.Listening = state == TCP_LISTEN_STATE
+		} else if state == UDP_UNCONNECTED_STATE {
+			// Unconnected UDP sockets receive from anyone, which is what listening means for UDP
+			s.State = "UNCONN"
+			s.Listening = true
+		} else {
+			s.State = tcpStates[state]
+		}
+
+		sockets = append(sockets, s)
+	}
+
+	return sockets
+}
+
+// parseInetAddress turns "0100007F:1F90" into "127.0.0.1:8080". Addresses are
+// written as 32-bit words in host byte order, ports as plain hex.
+func parseInetAddress(field string) string {
+	hexIP, hexPort, found := strings.Cut(field, ":")
+
+	if !found {
+		return field
+	}
+
+	raw, err := hex.DecodeString(hexIP)
+	if err != nil || len(raw)%4 != 0 {
+		return field
+	}
+
+	// Each word is printed as a host integer; storing it back in host order gives network order
+	for i := 0; i < len(raw); i += 4 {
+		binary.NativeEndian.PutUint32(raw[i:], binary.BigEndian.Uint32(raw[i:]))
+	}
+
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return field
+	}
+
+	return net.JoinHostPort(net.IP(raw).String(), strconv.FormatUint(port, 10))
+}
+
+func parseUnixTable(content []byte) []Socket {
+	var sockets []Socket
+
+	for _, line := range strings.Split(string(content), "\n")[1:] {
+		fields := strings.Fields(line)
+
+		if len(fields) <= UNIX_INODE {
+			continue
+		}
+
+		s := Socket{
+			Protocol: "unix",
+			Inode:    fields[UNIX_INODE],
+			State:    unixType(fields[UNIX_TYPE]),
+		}
+
+		if len(fields) > UNIX_PATH {
+			s.Local = fields[UNIX_PATH]
+		}
+
+		flags, err := strconv.ParseUint(fields[UNIX_FLAGS], 16, 32)
+		if err == nil && flags&UNIX_ACCEPTING_FLAG != 0 {
+			s.Listening = true
+			s.State += " LISTEN"
+		} else if fields[UNIX_STATE] == UNIX_CONNECTED_STATE {
+			s.State += " CONNECTED"
+		}
+
+		sockets = append(sockets, s)
+	}
+
+	return sockets
+}
+
+func unixType(socketType string) string {
+	switch socketType {
+	case UNIX_STREAM_TYPE:
+		return "STREAM"
+	case UNIX_DGRAM_TYPE:
+		return "DGRAM"
+	case UNIX_SEQPACKET_TYPE:
+		return "SEQPACKET"
+	}
+
+	return socketType
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Settings the UI keeps between runs
+type Config struct {
+	Columns []string `json:"columns,omitempty"` // process list columns, in order
+	Layout  Layout   `json:"layout"`
+}
+
+// Layout of the panels above the process list
+type Layout struct {
+	HideCPU    bool   `json:"hide_cpu,omitempty"`
+	HideMemory bool   `json:"hide_memory,omitempty"`
+	CPUMeters  string `json:"cpu_meters,omitempty"`  // "auto", cores per row, or "heatmap"
+	MeterShare int    `json:"meter_share,omitempty"` // percent of the screen height the panels may take
+}
+
+// Path is $XDG_CONFIG_HOME/titop/config.json, or ~/.config/titop/config.json
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "titop", "config.json"), nil
+}
+
+// Load reads the config file. A missing file is an empty config, and so is
+// the config returned with an error.
+func Load() (Config, error) {
+	var cfg Config
+
+	path, err := Path()
+	if err != nil {
+		return cfg, err
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		// Whatever got decoded before the error is left out too
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Save writes the config file, creating its directory when needed. The file
+// is replaced whole so a crash never leaves half of it behind.
+func Save(cfg Config) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(content, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,81 @@
+package format
+
+import "fmt"
+
+// Units selects how byte quantities are scaled and labelled.
+type Units int
+
+const (
+	IEC Units = iota // powers of 1024: KiB, MiB, GiB, TiB
+	SI               // powers of 1000: kB, MB, GB, TB
+)
+
+var units = IEC
+
+var iecSuffixes = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+var siSuffixes = []string{"B", "kB", "MB", "GB", "TB", "PB"}
+
+func (u Units) String() string {
+	if u == SI {
+		return "SI"
+	}
+
+	return "IEC"
+}
+
+func SetUnits(u Units) {
+	units = u
+}
+
+func GetUnits() Units {
+	return units
+}
+
+func ToggleUnits() {
+	if units == IEC {
+		units = SI
+	} else {
+		units = IEC
+	}
+}
+
+// Bytes auto-scales b to the largest unit that keeps the value at or above one.
+func Bytes(b int64) string {
+	base := 1024.0
+	suffixes := iecSuffixes
+
+	if units == SI {
+		base = 1000.0
+		suffixes = siSuffixes
+	}
+
+	sign := ""
+	if b < 0 {
+		sign = "-"
+		b = -b
+	}
+
+	value := float64(b)
+	idx := 0
+
+	for value >= base && idx < len(suffixes)-1 {
+		value /= base
+		idx++
+	}
+
+	if idx == 0 {
+		return fmt.Sprintf("%s%d%s", sign, b, suffixes[idx])
+	}
+
+	return fmt.Sprintf("%s%.1f%s", sign, value, suffixes[idx])
+}
+
+// KiB formats a value reported by the kernel in kibibytes, as /proc/meminfo does.
+func KiB(kib int64) string {
+	return Bytes(kib * 1024)
+}
+
+// Rate formats a per-second byte rate.
+func Rate(bytesPerSecond int64) string {
+	return Bytes(bytesPerSecond) + "/s"
+}
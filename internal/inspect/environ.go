@@ -0,0 +1,74 @@
+package inspect
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/amirdaraby/titop/internal/reader"
+)
+
+const REDACTED_VALUE = "********"
+
+// Parts of variable names that hint at a secret value
+var secretKeyParts = []string{
+	"SECRET", "TOKEN", "PASSW", "PASSPHRASE", "CREDENTIAL", "AUTH",
+	"API_KEY", "APIKEY", "ACCESS_KEY", "PRIVATE_KEY", "COOKIE", "SESSION",
+}
+
+type EnvironmentVariable struct {
+	Key, Value string
+	Redacted   bool // the value looked like a secret and was hidden
+}
+
+// Environment lists the environment a process was started with, sorted by
+// key, keeping only variables whose key or value contains filter. Values of
+// secret-looking keys are redacted.
+func Environment(pid, filter string) ([]EnvironmentVariable, error) {
+	content, err := reader.ReadEnviron(pid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter = strings.ToLower(filter)
+	var variables []EnvironmentVariable
+
+	for _, entry := range strings.Split(string(content), "\x00") {
+		key, value, found := strings.Cut(entry, "=")
+		if !found {
+			continue
+		}
+
+		variable := EnvironmentVariable{Key: key, Value: value}
+		if isSecretKey(key) {
+			variable.Value = REDACTED_VALUE
+			variable.Redacted = true
+		}
+
+		// Match what is shown, so the filter can't be used to guess a secret
+		shown := strings.ToLower(variable.Key + "=" + variable.Value)
+		if filter != "" && !strings.Contains(shown, filter) {
+			continue
+		}
+
+		variables = append(variables, variable)
+	}
+
+	sort.Slice(variables, func(i, j int) bool {
+		return variables[i].Key < variables[j].Key
+	})
+
+	return variables, nil
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToUpper(key)
+
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,97 @@
+package inspect
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/amirdaraby/titop/internal/collect/sock"
+	"github.com/amirdaraby/titop/internal/reader"
+)
+
+// Kinds of open files, as lsof names them
+const (
+	FILE_TYPE_REGULAR = "REG"
+	FILE_TYPE_DEVICE  = "DEV"
+	FILE_TYPE_SOCKET  = "SOCK"
+	FILE_TYPE_PIPE    = "PIPE"
+	FILE_TYPE_ANON    = "ANON"
+	FILE_TYPE_DELETED = "DEL"
+)
+
+type OpenFile struct {
+	FD     int
+	Type   string // one of the FILE_TYPE_ constants
+	Target string // path, or what the fd link points to for pipes and anonymous inodes
+	Socket *sock.Socket
+}
+
+// OpenFiles lists the open file descriptors of a process, sorted by number.
+// Sockets are joined to the socket tables of the process's network namespace
+// by inode.
+func OpenFiles(pid string) ([]OpenFile, error) {
+	targets, err := reader.ReadFds(pid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var sockets map[string]sock.Socket
+	var files []OpenFile
+
+	for fdName, target := range targets {
+		fd, err := strconv.Atoi(fdName)
+		if err != nil {
+			continue
+		}
+
+		file := OpenFile{FD: fd, Target: target, Type: fileType(target)}
+
+		if inode, found := socketInode(target); found {
+			// Read the socket tables only for processes that have sockets
+			if sockets == nil {
+				sockets = sock.Sockets(pid)
+			}
+
+			if s, exists := sockets[inode]; exists {
+				file.Socket = &s
+			}
+		}
+
+		files = append(files, file)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].FD < files[j].FD
+	})
+
+	return files, nil
+}
+
+func fileType(target string) string {
+	switch {
+	case strings.HasPrefix(target, "socket:["):
+		return FILE_TYPE_SOCKET
+	case strings.HasPrefix(target, "pipe:["):
+		return FILE_TYPE_PIPE
+	case strings.HasPrefix(target, "anon_inode:"):
+		return FILE_TYPE_ANON
+	case strings.HasSuffix(target, " (deleted)"):
+		return FILE_TYPE_DELETED
+	case strings.HasPrefix(target, "/dev/"):
+		return FILE_TYPE_DEVICE
+	}
+
+	return FILE_TYPE_REGULAR
+}
+
+// socketInode takes the inode out of a "socket:[12345]" link
+func socketInode(target string) (string, bool) {
+	inode, found := strings.CutPrefix(target, "socket:[")
+
+	if !found {
+		return "", false
+	}
+
+	return strings.TrimSuffix(inode, "]"), true
+}
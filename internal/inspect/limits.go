@@ -0,0 +1,160 @@
+package inspect
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/amirdaraby/titop/internal/reader"
+)
+
+// Value of a limit without a bound
+const UNLIMITED = -1
+
+// Columns of the /proc/[pid]/limits header, found by name since the limit
+// names hold spaces
+const (
+	LIMITS_SOFT_COLUMN  = "Soft Limit"
+	LIMITS_HARD_COLUMN  = "Hard Limit"
+	LIMITS_UNITS_COLUMN = "Units"
+)
+
+type Limit struct {
+	Name       string
+	Soft, Hard int64 // UNLIMITED when unbounded
+	Unit       string
+	Used       int64
+	HasUsage   bool // Used is known for this limit
+	UsageLabel string
+}
+
+// Limits lists the resource limits of a process, with the current usage of
+// the ones it can be measured for: open files, threads of the user, address
+// space, resident set, stack, locked memory and pending signals. userThreads
+// counts the threads of a user, which the caller has already sampled.
+func Limits(pid string, userThreads func(uid string) int64) ([]Limit, error) {
+	content, err := reader.ReadLimits(pid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	softStart := strings.Index(lines[0], LIMITS_SOFT_COLUMN)
+	hardStart := strings.Index(lines[0], LIMITS_HARD_COLUMN)
+	unitsStart := strings.Index(lines[0], LIMITS_UNITS_COLUMN)
+
+	if softStart < 0 || hardStart < softStart || unitsStart < hardStart {
+		return nil, nil
+	}
+
+	status := statusValues(pid)
+	var limits []Limit
+
+	for _, line := range lines[1:] {
+		if len(line) <= hardStart {
+			continue
+		}
+
+		limit := Limit{
+			Name: strings.TrimSpace(line[:softStart]),
+			Soft: parseLimit(line[softStart:hardStart]),
+			Hard: parseLimit(line[hardStart:min(unitsStart, len(line))]),
+		}
+		if len(line) > unitsStart {
+			limit.Unit = strings.TrimSpace(line[unitsStart:])
+		}
+
+		limit.Used, limit.UsageLabel, limit.HasUsage = limitUsage(pid, limit.Name, status, userThreads)
+		limits = append(limits, limit)
+	}
+
+	return limits, nil
+}
+
+// limitUsage measures what a limit bounds, when that is cheap to find out
+func limitUsage(pid, name string, status map[string]string, userThreads func(uid string) int64) (int64, string, bool) {
+	switch name {
+	case "Max open files":
+		fds, err := reader.ReadFds(pid)
+		if err != nil {
+			return 0, "", false
+		}
+		return int64(len(fds)), "open fds", true
+	case "Max processes":
+		// RLIMIT_NPROC bounds every thread of the process's real user
+		uid := strings.Fields(status["Uid"])
+		if len(uid) == 0 {
+			return 0, "", false
+		}
+		return userThreads(uid[0]), "threads of user", true
+	case "Max address space":
+		return statusBytes(status, "VmSize")
+	case "Max resident set":
+		return statusBytes(status, "VmRSS")
+	case "Max stack size":
+		return statusBytes(status, "VmStk")
+	case "Max locked memory":
+		return statusBytes(status, "VmLck")
+	case "Max pending signals":
+		// SigQ is "queued/limit" for the real user
+		queued, _, _ := strings.Cut(status["SigQ"], "/")
+		n, err := strconv.ParseInt(queued, 10, 64)
+		if err != nil {
+			return 0, "", false
+		}
+		return n, "queued", true
+	}
+
+	return 0, "", false
+}
+
+func statusValues(pid string) map[string]string {
+	values := make(map[string]string)
+	content, err := reader.ReadStatus(pid)
+
+	if err != nil {
+		return values
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if key, value, found := strings.Cut(line, ":"); found {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return values
+}
+
+// statusBytes returns a "<n> kB" status field in bytes
+func statusBytes(status map[string]string, key string) (int64, string, bool) {
+	fields := strings.Fields(status[key])
+	if len(fields) == 0 {
+		return 0, "", false
+	}
+
+	kib, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+
+	return kib * 1024, key, true
+}
+
+func parseLimit(value string) int64 {
+	value = strings.TrimSpace(value)
+
+	if value == "unlimited" {
+		return UNLIMITED
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return UNLIMITED
+	}
+
+	return n
+}
@@ -0,0 +1,178 @@
+package inspect
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/amirdaraby/titop/internal/reader"
+)
+
+// Kinds of memory mappings
+const (
+	MAPPING_KIND_HEAP    = "heap"
+	MAPPING_KIND_STACK   = "stack"
+	MAPPING_KIND_ANON    = "anon"
+	MAPPING_KIND_LIBRARY = "lib"
+	MAPPING_KIND_FILE    = "file"
+	MAPPING_KIND_SPECIAL = "special" // [vdso], [vvar] and friends
+)
+
+// Orders memory mappings can be sorted in, largest first
+const (
+	MAPPING_SORT_RESIDENT MappingSort = iota
+	MAPPING_SORT_PROPORTIONAL
+	MAPPING_SORT_SWAP
+	MAPPING_SORT_DIRTY
+	MAPPING_SORT_SIZE
+	MAPPING_SORT_COUNT // number of orders, not an order itself
+)
+
+// Name the anonymous mappings are summed under
+const ANONYMOUS_MAPPING_NAME = "[anon]"
+
+// Number of fields of an smaps header line before the pathname
+const SMAPS_HEADER_FIELDS = 5
+
+type MappingSort int
+
+// Mapping sums every region of /proc/[pid]/smaps mapping the same thing,
+// e.g. the text, data and bss of a shared library. Sizes are in bytes.
+type Mapping struct {
+	Name         string
+	Kind         string // one of the MAPPING_KIND_ constants
+	Regions      int
+	Size         int64
+	Resident     int64
+	Proportional int64
+	Swap         int64
+	Dirty        int64 // shared and private dirty pages
+}
+
+// MemoryMaps sums the memory maps of a process by what they map, sorted in
+// the given order.
+func MemoryMaps(pid string, by MappingSort) ([]Mapping, error) {
+	content, err := reader.ReadSmaps(pid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []Mapping
+	indexes := make(map[string]int)
+	var current *Mapping
+
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Attribute lines start with a "Key:", every other line starts a region
+		if !strings.HasSuffix(fields[0], ":") {
+			name := mappingName(line)
+
+			idx, exists := indexes[name]
+			if !exists {
+				idx = len(mappings)
+				indexes[name] = idx
+				mappings = append(mappings, Mapping{Name: name, Kind: mappingKind(name)})
+			}
+
+			current = &mappings[idx]
+			current.Regions++
+			continue
+		}
+
+		if current == nil || len(fields) < 2 {
+			continue
+		}
+
+		kib, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch strings.TrimSuffix(fields[0], ":") {
+		case "Size":
+			current.Size += kib * 1024
+		case "Rss":
+			current.Resident += kib * 1024
+		case "Pss":
+			current.Proportional += kib * 1024
+		case "Swap":
+			current.Swap += kib * 1024
+		case "Shared_Dirty", "Private_Dirty":
+			current.Dirty += kib * 1024
+		}
+	}
+
+	SortMappings(mappings, by)
+
+	return mappings, nil
+}
+
+// SortMappings sorts mappings largest first, by name when they are equal
+func SortMappings(mappings []Mapping, by MappingSort) {
+	sort.SliceStable(mappings, func(i, j int) bool {
+		a, b := mappings[i].sortValue(by), mappings[j].sortValue(by)
+
+		if a != b {
+			return a > b
+		}
+
+		return mappings[i].Name < mappings[j].Name
+	})
+}
+
+func (m Mapping) sortValue(by MappingSort) int64 {
+	switch by {
+	case MAPPING_SORT_PROPORTIONAL:
+		return m.Proportional
+	case MAPPING_SORT_SWAP:
+		return m.Swap
+	case MAPPING_SORT_DIRTY:
+		return m.Dirty
+	case MAPPING_SORT_SIZE:
+		return m.Size
+	}
+
+	return m.Resident
+}
+
+// mappingName takes the pathname out of an smaps header line. It may hold
+// spaces, so it's everything after the first five fields.
+func mappingName(header string) string {
+	rest := header
+
+	for range SMAPS_HEADER_FIELDS {
+		rest = strings.TrimLeft(rest, " ")
+		_, rest, _ = strings.Cut(rest, " ")
+	}
+
+	name := strings.TrimSpace(rest)
+	if name == "" {
+		return ANONYMOUS_MAPPING_NAME
+	}
+
+	return name
+}
+
+func mappingKind(name string) string {
+	switch {
+	case name == "[heap]":
+		return MAPPING_KIND_HEAP
+	case strings.HasPrefix(name, "[stack"):
+		return MAPPING_KIND_STACK
+	case name == ANONYMOUS_MAPPING_NAME, strings.HasPrefix(name, "[anon"):
+		return MAPPING_KIND_ANON
+	case strings.HasPrefix(name, "["):
+		return MAPPING_KIND_SPECIAL
+	case strings.Contains(path.Base(name), ".so"):
+		return MAPPING_KIND_LIBRARY
+	}
+
+	return MAPPING_KIND_FILE
+}
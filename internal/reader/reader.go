@@ -1,10 +1,33 @@
 package reader
 
 import (
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
 )
 
+type ProcessContent struct {
+	Files  map[string][]byte
+	ReadAt time.Time // taken right after stat is read; carries a monotonic clock reading
+}
+
+type CgroupContent struct {
+	Path   string // relative to the cgroup2 root, "/" for the root itself
+	Files  map[string][]byte
+	ReadAt time.Time
+}
+
+// Namespaces whose /proc/[pid]/ns links are read along with each process
+var namespaceLinks = []string{"pid", "net", "mnt"}
+
+// Where the cgroup2 hierarchy is mounted on unified and hybrid systems
+var cgroup2Roots = []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"}
+
 func ReadMemInfo() (memInfoContent []byte, err error) {
 
 	memInfoContent, err = os.ReadFile("/proc/meminfo")
@@ -25,7 +48,23 @@ func ReadUptime() (uptimeContent []byte, err error) {
 	return
 }
 
-func ReadProcesses() (processesContent []map[string][]byte) {
+const passwdPath = "/etc/passwd"
+
+func ReadPasswd() (passwdContent []byte, err error) {
+	passwdContent, err = os.ReadFile(passwdPath)
+
+	return
+}
+
+// StatPasswd tells whether /etc/passwd changed without reading it
+func StatPasswd() (os.FileInfo, error) {
+	return os.Stat(passwdPath)
+}
+
+// ReadProcesses returns the stat, statm, io, status and cgroup files and the
+// namespace links of every process,
+// plus any optional files named in extraFiles (e.g. "smaps_rollup") that are readable.
+func ReadProcesses(extraFiles ...string) (processesContent []ProcessContent) {
 	dirEntries, err := os.ReadDir("/proc")
 
 	if err != nil {
@@ -50,6 +89,8 @@ func ReadProcesses() (processesContent []map[string][]byte) {
 			continue
 		}
 
+		readAt := time.Now()
+
 		memStatContent, err := os.ReadFile("/proc/" + dirName + "/statm")
 
 		if err != nil {
@@ -58,21 +99,236 @@ func ReadProcesses() (processesContent []map[string][]byte) {
 
 		processMap := make(map[string][]byte)
 
+		cgroupContent, err := os.ReadFile("/proc/" + dirName + "/cgroup")
+
+		if err == nil {
+			processMap["cgroup"] = cgroupContent
+		}
+
 		diskStatContent, err := os.ReadFile("/proc/"+dirName+"/io")
 
 		if err == nil {
 			processMap["io"] = diskStatContent
 		}
 
+		statusContent, err := os.ReadFile("/proc/" + dirName + "/status")
+
+		if err == nil {
+			processMap["status"] = statusContent
+		}
+
+		// Links are stored by target, e.g. "ns/net" holds "net:[4026531833]"
+		for _, ns := range namespaceLinks {
+			target, err := os.Readlink("/proc/" + dirName + "/ns/" + ns)
+
+			if err == nil {
+				processMap["ns/"+ns] = []byte(target)
+			}
+		}
+
+		for _, name := range extraFiles {
+			content, err := os.ReadFile("/proc/" + dirName + "/" + name)
+
+			if err == nil {
+				processMap[name] = content
+			}
+		}
+
 		processMap["stat"] = statContent
 		processMap["statm"] = memStatContent
 
-		processesContent = append(processesContent, processMap)
+		processesContent = append(processesContent, ProcessContent{
+			Files:  processMap,
+			ReadAt: readAt,
+		})
 	}
 
 	return processesContent
 }
 
+// ReadThreads returns the stat and io files of every thread of a process.
+func ReadThreads(pid string) (threadsContent []ProcessContent) {
+	taskDir := "/proc/" + pid + "/task/"
+
+	dirEntries, err := os.ReadDir(taskDir)
+
+	if err != nil {
+		return nil
+	}
+
+	for _, d := range dirEntries {
+		statContent, err := os.ReadFile(taskDir + d.Name() + "/stat")
+
+		if err != nil {
+			continue
+		}
+
+		readAt := time.Now()
+
+		threadMap := make(map[string][]byte)
+
+		ioContent, err := os.ReadFile(taskDir + d.Name() + "/io")
+
+		if err == nil {
+			threadMap["io"] = ioContent
+		}
+
+		threadMap["stat"] = statContent
+
+		threadsContent = append(threadsContent, ProcessContent{
+			Files:  threadMap,
+			ReadAt: readAt,
+		})
+	}
+
+	return threadsContent
+}
+
+// CgroupRoot returns the mount point of the cgroup2 hierarchy, or an empty
+// string on systems that only have cgroup v1.
+func CgroupRoot() string {
+	for _, root := range cgroup2Roots {
+		if _, err := os.Stat(root + "/cgroup.controllers"); err == nil {
+			return root
+		}
+	}
+
+	return ""
+}
+
+// ReadCgroups walks the cgroup2 hierarchy and returns the named files of every
+// cgroup. Files a cgroup doesn't have, e.g. of disabled controllers, are left out.
+func ReadCgroups(files ...string) (cgroupsContent []CgroupContent) {
+	root := CgroupRoot()
+
+	if root == "" {
+		return nil
+	}
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		// Cgroups removed while walking are skipped, not fatal
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+
+		cgroupMap := make(map[string][]byte)
+
+		for _, name := range files {
+			content, err := os.ReadFile(path + "/" + name)
+
+			if err == nil {
+				cgroupMap[name] = content
+			}
+		}
+
+		relative := "/" + strings.TrimPrefix(strings.TrimPrefix(path, root), "/")
+
+		cgroupsContent = append(cgroupsContent, CgroupContent{
+			Path:   relative,
+			Files:  cgroupMap,
+			ReadAt: time.Now(),
+		})
+
+		return nil
+	})
+
+	return cgroupsContent
+}
+
+// ReadNet reads a socket table such as "tcp" or "unix" from /proc/net, or
+// from /proc/[pid]/net to see the network namespace of that process.
+func ReadNet(pid, name string) (netContent []byte, err error) {
+	if pid == "" {
+		netContent, err = os.ReadFile("/proc/net/" + name)
+	} else {
+		netContent, err = os.ReadFile("/proc/" + pid + "/net/" + name)
+	}
+
+	return
+}
+
+// ReadStatus returns the status file of a process, fresher than the one
+// ReadProcesses read with the rest of the sample
+func ReadStatus(pid string) (statusContent []byte, err error) {
+	statusContent, err = os.ReadFile("/proc/" + pid + "/status")
+
+	return
+}
+
+// ReadEnviron returns the NUL-separated environment a process started with
+func ReadEnviron(pid string) (environContent []byte, err error) {
+	environContent, err = os.ReadFile("/proc/" + pid + "/environ")
+
+	return
+}
+
+// ReadLimits returns the resource limits table of a process
+func ReadLimits(pid string) (limitsContent []byte, err error) {
+	limitsContent, err = os.ReadFile("/proc/" + pid + "/limits")
+
+	return
+}
+
+// ReadSmaps returns every memory mapping of a process with its page counts
+func ReadSmaps(pid string) (smapsContent []byte, err error) {
+	smapsContent, err = os.ReadFile("/proc/" + pid + "/smaps")
+
+	return
+}
+
+// ReadPIDs lists the IDs of all running processes
+func ReadPIDs() (pids []string) {
+	dirEntries, err := os.ReadDir("/proc")
+
+	if err != nil {
+		return nil
+	}
+
+	for _, d := range dirEntries {
+		if _, err := strconv.Atoi(d.Name()); err == nil && d.IsDir() {
+			pids = append(pids, d.Name())
+		}
+	}
+
+	return pids
+}
+
+// ReadFds returns the link target of every open file descriptor of a process
+func ReadFds(pid string) (targets map[string]string, err error) {
+	fdDir := "/proc/" + pid + "/fd/"
+
+	dirEntries, err := os.ReadDir(fdDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	targets = make(map[string]string)
+
+	for _, d := range dirEntries {
+		// Descriptors closed while listing are skipped
+		target, err := os.Readlink(fdDir + d.Name())
+
+		if err == nil {
+			targets[d.Name()] = target
+		}
+	}
+
+	return targets, nil
+}
+
+func ReadMountInfo() (mountInfoContent []byte, err error) {
+	mountInfoContent, err = os.ReadFile("/proc/self/mountinfo")
+
+	return
+}
+
+func ReadStatfs(mountPoint string) (stat unix.Statfs_t, err error) {
+	err = unix.Statfs(mountPoint, &stat)
+
+	return
+}
+
 func ReadDiskStat() (diskStatContent []byte, err error) {
 	diskStatContent, err = os.ReadFile("/proc/diskstats")
 	
@@ -1,6 +1,7 @@
 package shared
 
 import (
+	"sync"
 	"time"
 
 	"github.com/tklauser/go-sysconf"
@@ -12,11 +13,12 @@ type Config struct {
 	CoresCount int64
 	PageSize int64
 	TotalMem int64 // in RSS
+	BootTime time.Time // start times of processes count from it
 }
 
 var cfg *Config
 var refreshRate int = 2000 // ms
-var lastRefresh time.Time
+var refreshRateMu sync.Mutex // the UI changes the rate while the sampler reads it
 
 func Init() error {
 	clktck, err := sysconf.Sysconf(sysconf.SC_CLK_TCK)
@@ -44,11 +46,18 @@ func Init() error {
 
 	totalMem := memPages * pageSize
 
+	uptime, err := GetUptime()
+
+	if err != nil {
+		return err
+	}
+
 	cfg = &Config{
 		ClkTck:     clktck,
 		CoresCount: numCores,
 		PageSize:   pageSize,
 		TotalMem:   totalMem,
+		BootTime:   time.Now().Add(-time.Duration(uptime) * time.Second),
 	}
 
 	return nil
@@ -74,25 +83,26 @@ func GetConfig() *Config {
 }
 
 func IncreaseRefreshRate(increase int) {
+	refreshRateMu.Lock()
+	defer refreshRateMu.Unlock()
+
 	if refreshRate + increase <= 10000 {
 		refreshRate += increase
 	}
 }
 
 func DecreaseRefreshRate(decrease int) {
+	refreshRateMu.Lock()
+	defer refreshRateMu.Unlock()
+
 	if refreshRate - decrease >= 100 {
 		refreshRate -= decrease
 	}
 }
 
 func GetRefreshRate() int {
-	return refreshRate
-}
+	refreshRateMu.Lock()
+	defer refreshRateMu.Unlock()
 
-func Refreshing() {
-	lastRefresh = time.Now()
-}
-
-func GetLastRefresh() time.Time {
-	return lastRefresh
+	return refreshRate
 }
\ No newline at end of file
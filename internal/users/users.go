@@ -0,0 +1,85 @@
+package users
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amirdaraby/titop/internal/reader"
+)
+
+// Looked up by the UI while the process collector refreshes them
+var (
+	mu            sync.Mutex
+	names         map[string]string
+	loadedModTime time.Time
+)
+
+// Lookup resolves a uid to a user name from the cached /etc/passwd. Unknown
+// uids are returned as-is, which also covers users from NSS sources other
+// than /etc/passwd.
+func Lookup(uid string) string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if names == nil {
+		refresh()
+	}
+
+	if name, exists := names[uid]; exists {
+		return name
+	}
+
+	return uid
+}
+
+// Refresh parses /etc/passwd again if it changed since it was last read.
+func Refresh() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	refresh()
+}
+
+func refresh() {
+	// Without a passwd file every uid stays a number, rather than every
+	// lookup trying to read it again
+	if names == nil {
+		names = make(map[string]string)
+	}
+
+	info, err := reader.StatPasswd()
+
+	if err != nil || info.ModTime().Equal(loadedModTime) {
+		return
+	}
+
+	passwdContent, err := reader.ReadPasswd()
+
+	if err != nil {
+		return
+	}
+
+	names = parsePasswd(passwdContent)
+	loadedModTime = info.ModTime()
+}
+
+// parsePasswd maps uids to names from "name:password:uid:gid:gecos:home:shell" lines
+func parsePasswd(content []byte) map[string]string {
+	parsed := make(map[string]string)
+
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Split(line, ":")
+
+		if len(fields) < 3 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// The first entry wins, as it does for getpwuid
+		if _, exists := parsed[fields[2]]; !exists {
+			parsed[fields[2]] = fields[0]
+		}
+	}
+
+	return parsed
+}
@@ -2,20 +2,107 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/amirdaraby/titop/internal/alert"
 	titop "github.com/amirdaraby/titop/internal/application"
+	"github.com/amirdaraby/titop/internal/collect"
+	"github.com/amirdaraby/titop/internal/collect/cgroup"
+	"github.com/amirdaraby/titop/internal/collect/cpu"
+	"github.com/amirdaraby/titop/internal/collect/disk"
+	"github.com/amirdaraby/titop/internal/collect/mem"
+	"github.com/amirdaraby/titop/internal/collect/proc"
+	"github.com/amirdaraby/titop/internal/config"
+	"github.com/amirdaraby/titop/internal/format"
 	"github.com/amirdaraby/titop/internal/shared"
 )
 
 func main() {
+	collect.Register(cpu.Collector{}, mem.Collector{}, proc.Collector{}, cgroup.Collector{}, disk.Collector{})
+
+	si := flag.Bool("si", false, "show sizes in SI units (powers of 1000) instead of IEC (powers of 1024)")
+	alerts := flag.String("alerts", "", "JSON file of alert rules")
+	disable := flag.String("disable", "", "comma-separated collectors not to run, of "+strings.Join(collect.Names(), ", "))
+	intervals := flag.String("interval", "", "comma-separated collector=duration pairs to run collectors less often, e.g. filesystems=10s")
+	flag.Parse()
+
+	if *si {
+		format.SetUnits(format.SI)
+	}
+
+	if *alerts != "" {
+		rules, err := alert.Load(*alerts)
+
+		if err != nil {
+			exitWithUsageError(err)
+		}
+
+		alert.SetRules(rules)
+	}
+
+	if err := configureCollectors(*disable, *intervals); err != nil {
+		exitWithUsageError(err)
+	}
+
+	// A broken config file shouldn't keep titop from starting, the defaults
+	// are used and the error shown once it runs
+	settings, settingsErr := config.Load()
 
 	if err := shared.Init(); err != nil {
 		panic(err)
 	}
 
-	ctx := context.Background()
+	// Quit cleanly when killed too, so the terminal gets restored
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
 
-	if err := titop.Run(ctx); err != nil {
+	if err := titop.Run(ctx, settings, settingsErr); err != nil {
 		panic(err)
 	}
 }
+
+// configureCollectors applies the -disable and -interval flags
+func configureCollectors(disable, intervals string) error {
+	for _, name := range strings.Split(disable, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+
+		if err := collect.SetEnabled(name, false); err != nil {
+			return err
+		}
+	}
+
+	for _, pair := range strings.Split(intervals, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("interval %q is not collector=duration", pair)
+		}
+
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		if err := collect.SetInterval(name, interval); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exitWithUsageError(err error) {
+	fmt.Fprintln(os.Stderr, "titop:", err)
+	os.Exit(2)
+}